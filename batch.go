@@ -0,0 +1,74 @@
+package ttlmap
+
+import "time"
+
+// Entry is a value to be stored by MSetEntries
+type Entry struct {
+	Value   interface{}
+	TTL     *time.Duration // nil uses the cache default
+	Cleanup func(*Item)
+}
+
+// MSet sets all the given values with the same duration, taking each shard lock once
+func (m CacheMap) MSet(data map[string]interface{}, duration time.Duration) {
+	items := make(map[string]*Item, len(data))
+	for key, value := range data {
+		items[key] = m.newItem(value, itemOptions{duration: &duration})
+	}
+	m.setItems(items)
+}
+
+// MSetEntries sets all the given entries with their own TTL and cleanup, taking each shard lock once
+func (m CacheMap) MSetEntries(entries map[string]Entry) {
+	items := make(map[string]*Item, len(entries))
+	for key, e := range entries {
+		items[key] = m.newItem(e.Value, itemOptions{duration: e.TTL, onDelete: e.Cleanup})
+	}
+	m.setItems(items)
+}
+
+// MGet retrieves the unexpired values for the given keys, increasing their expiry time, taking each shard lock once
+func (m CacheMap) MGet(keys []string) map[string]interface{} {
+	found := make(map[string]interface{}, len(keys))
+	expired := make(map[string]*Item)
+	m.shards.lockEach(keys, (*CacheMapShared).RLock, (*CacheMapShared).RUnlock, func(shard *CacheMapShared, keys []string) {
+		for _, key := range keys {
+			itm, ok := shard.items[key]
+			if ok && !itm.Expired() && itm.access(true) {
+				found[key] = itm.GetValue()
+			} else {
+				ok = false
+			}
+			m.stats.lookup(ok)
+			if itm != nil && itm.Expired() {
+				expired[key] = itm
+			}
+		}
+	})
+	for key, itm := range expired {
+		m.evict(key, itm)
+	}
+	return found
+}
+
+// MRemove removes the given keys, taking each shard lock once
+func (m CacheMap) MRemove(keys []string) {
+	m.shards.lockEach(keys, (*CacheMapShared).Lock, (*CacheMapShared).unlock, func(shard *CacheMapShared, keys []string) {
+		for _, key := range keys {
+			shard.remove(key, RemovedDeleted)
+		}
+	})
+}
+
+func (m CacheMap) setItems(items map[string]*Item) {
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+	m.shards.lockEach(keys, (*CacheMapShared).Lock, (*CacheMapShared).unlock, func(shard *CacheMapShared, keys []string) {
+		for _, key := range keys {
+			shard.set(key, items[key])
+		}
+		m.grow(shard)
+	})
+}
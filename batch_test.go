@@ -0,0 +1,40 @@
+package ttlmap_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/packaged/ttlmap"
+)
+
+func TestBatchOperations(t *testing.T) {
+	cache := ttlmap.New(ttlmap.WithCleanupDuration(5 * time.Millisecond))
+	defer cache.Close()
+
+	short := 10 * time.Millisecond
+	cleaned := 0
+	cache.MSetEntries(map[string]ttlmap.Entry{
+		"a": {Value: 1},
+		"b": {Value: 2, TTL: &short, Cleanup: func(*ttlmap.Item) { cleaned++ }},
+		"c": {Value: 3, Cleanup: func(*ttlmap.Item) { cleaned++ }},
+	})
+	cache.MSet(map[string]interface{}{"d": 4, "e": 5}, time.Minute)
+
+	found := cache.MGet([]string{"a", "b", "c", "d", "e", "missing"})
+	if len(found) != 5 || found["b"] != 2 || found["e"] != 5 {
+		t.Fatalf("unexpected values %v", found)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if found = cache.MGet([]string{"a", "b"}); len(found) != 1 || cleaned != 1 {
+		t.Fatalf("expected per entry TTL and cleanup, %v %d", found, cleaned)
+	}
+
+	cache.MRemove([]string{"a", "c", "d", "missing"})
+	if found = cache.MGet([]string{"a", "c", "d", "e"}); len(found) != 1 || found["e"] != 5 {
+		t.Fatalf("unexpected values after remove %v", found)
+	}
+	if cleaned != 2 {
+		t.Fatalf("expected cleanup on remove, got %d", cleaned)
+	}
+}
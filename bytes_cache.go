@@ -0,0 +1,262 @@
+package ttlmap
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrEntryTooLarge is returned when an entry does not fit within a BytesCache shard buffer
+var ErrEntryTooLarge = errors.New("ttlmap: entry is larger than the shard buffer")
+
+// Entry header layout: hash, expires, deadline, ttl, key length, value length
+const (
+	bytesHeaderSize = 8 + 8 + 8 + 8 + 2 + 4
+	bytesMaxKeySize = 1<<16 - 1
+)
+
+// BytesCache is a cache of []byte values stored in large preallocated ring buffers per shard.
+// The shard index holds no pointers, so the garbage collector does not scan each entry.
+// When a shard buffer is full the oldest entries are overwritten, hash collisions are treated as a miss.
+// Keys are hashed with the cache hasher, by default a randomly seeded maphash, which also picks the shard.
+type BytesCache struct {
+	items    []*bytesShard
+	options  cacheOptions
+	shutdown chan struct{}
+}
+
+type bytesShard struct {
+	sync.Mutex
+	index   map[uint64]uint32
+	buf     []byte
+	head    uint32 // start of the oldest entry
+	tail    uint32 // next write position
+	wrapAt  uint32 // end of the entries before the buffer wrapped
+	wrapped bool
+	count   int // entries held within the buffer, including replaced and removed entries
+}
+
+// NewBytesCache creates a new bytes cache
+func NewBytesCache(opts ...CacheOption) BytesCache {
+	bc := BytesCache{options: defaultCacheOptions(), shutdown: make(chan struct{})}
+	for _, opt := range opts {
+		opt(&bc.options)
+	}
+	bc.options.shardCount = powerOfTwo(bc.options.shardCount)
+	if bc.options.hasher == nil {
+		bc.options.hasher = newSeededHasher()
+	}
+
+	bc.items = make([]*bytesShard, bc.options.shardCount)
+	for i := 0; i < bc.options.shardCount; i++ {
+		bc.items[i] = &bytesShard{index: make(map[uint64]uint32), buf: make([]byte, bc.options.shardBytes)}
+	}
+
+	ticker := time.NewTicker(bc.options.cleanupDuration)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-bc.shutdown:
+				return
+			case <-ticker.C:
+				bc.Cleanup()
+			}
+		}
+	}()
+	return bc
+}
+
+// Close stops the cleanup background goroutine
+func (bc BytesCache) Close() {
+	select {
+	case <-bc.shutdown:
+		// already closed
+	default:
+		close(bc.shutdown)
+	}
+}
+
+// getShard masks the hash as the shard count is always a power of two
+func (bc BytesCache) getShard(hash uint64) *bytesShard {
+	return bc.items[hash&uint64(bc.options.shardCount-1)]
+}
+
+// Set copies the value into the cache under the specified key
+func (bc BytesCache) Set(key string, value []byte, duration *time.Duration) error {
+	size := bytesHeaderSize + len(key) + len(value)
+	if len(key) > bytesMaxKeySize || size > bc.options.shardBytes {
+		return ErrEntryTooLarge
+	}
+	if duration == nil {
+		duration = &bc.options.defaultCacheDuration
+	}
+
+	hash := bc.options.hasher(key)
+	now := time.Now()
+	shard := bc.getShard(hash)
+	shard.Lock()
+	pos := shard.alloc(uint32(size))
+	entry := shard.buf[pos : pos+uint32(size)]
+	binary.LittleEndian.PutUint64(entry[0:8], hash)
+	binary.LittleEndian.PutUint64(entry[8:16], uint64(now.Add(*duration).UnixNano()))
+	binary.LittleEndian.PutUint64(entry[16:24], uint64(now.Add(bc.options.maxLifetime).UnixNano()))
+	binary.LittleEndian.PutUint64(entry[24:32], uint64(*duration))
+	binary.LittleEndian.PutUint16(entry[32:34], uint16(len(key)))
+	binary.LittleEndian.PutUint32(entry[34:38], uint32(len(value)))
+	copy(entry[bytesHeaderSize:], key)
+	copy(entry[bytesHeaderSize+len(key):], value)
+	shard.index[hash] = pos
+	shard.Unlock()
+	return nil
+}
+
+// TouchGet retrieves a copy of the value for the key, and optionally increase its expiry time if found
+func (bc BytesCache) TouchGet(key string, touch bool) ([]byte, bool) {
+	hash := bc.options.hasher(key)
+	shard := bc.getShard(hash)
+	shard.Lock()
+	defer shard.Unlock()
+	entry, ok := shard.lookup(hash, key, time.Now())
+	if !ok {
+		return nil, false
+	}
+	if touch {
+		ttl := time.Duration(binary.LittleEndian.Uint64(entry[24:32]))
+		binary.LittleEndian.PutUint64(entry[8:16], uint64(time.Now().Add(ttl).UnixNano()))
+	}
+	keyLen := int(binary.LittleEndian.Uint16(entry[32:34]))
+	valLen := int(binary.LittleEndian.Uint32(entry[34:38]))
+	value := make([]byte, valLen)
+	copy(value, entry[bytesHeaderSize+keyLen:])
+	return value, true
+}
+
+// Get retrieves a copy of the value for the key, and increase its expiry time if found
+func (bc BytesCache) Get(key string) ([]byte, bool) {
+	return bc.TouchGet(key, true)
+}
+
+// Has checks to see if an item exists
+func (bc BytesCache) Has(key string) bool {
+	hash := bc.options.hasher(key)
+	shard := bc.getShard(hash)
+	shard.Lock()
+	_, ok := shard.lookup(hash, key, time.Now())
+	shard.Unlock()
+	return ok
+}
+
+// Remove removes an element from the cache, its bytes are reclaimed when the ring buffer wraps
+func (bc BytesCache) Remove(key string) {
+	hash := bc.options.hasher(key)
+	shard := bc.getShard(hash)
+	shard.Lock()
+	if _, ok := shard.lookup(hash, key, time.Now()); ok {
+		delete(shard.index, hash)
+	}
+	shard.Unlock()
+}
+
+// Len returns the number of indexed entries, including expired entries not yet cleaned up
+func (bc BytesCache) Len() int {
+	count := 0
+	for _, shard := range bc.items {
+		shard.Lock()
+		count += len(shard.index)
+		shard.Unlock()
+	}
+	return count
+}
+
+// Flush removes all entries, keeping the preallocated buffers
+func (bc BytesCache) Flush() {
+	for _, shard := range bc.items {
+		shard.Lock()
+		shard.index = make(map[uint64]uint32)
+		shard.reset()
+		shard.Unlock()
+	}
+}
+
+// Cleanup removes any expired entries from the index
+func (bc BytesCache) Cleanup() {
+	for _, shard := range bc.items {
+		shard.Lock()
+		now := time.Now().UnixNano()
+		for hash, pos := range shard.index {
+			if shard.expired(shard.buf[pos:], now) {
+				delete(shard.index, hash)
+			}
+		}
+		shard.Unlock()
+	}
+}
+
+// lookup returns the live entry for the key, removing it from the index if it has expired
+func (bs *bytesShard) lookup(hash uint64, key string, now time.Time) ([]byte, bool) {
+	pos, ok := bs.index[hash]
+	if !ok {
+		return nil, false
+	}
+	entry := bs.buf[pos:]
+	keyLen := int(binary.LittleEndian.Uint16(entry[32:34]))
+	if string(entry[bytesHeaderSize:bytesHeaderSize+keyLen]) != key {
+		// hash collision
+		return nil, false
+	}
+	if bs.expired(entry, now.UnixNano()) {
+		delete(bs.index, hash)
+		return nil, false
+	}
+	return entry, true
+}
+
+func (bs *bytesShard) expired(entry []byte, now int64) bool {
+	expires := int64(binary.LittleEndian.Uint64(entry[8:16]))
+	deadline := int64(binary.LittleEndian.Uint64(entry[16:24]))
+	return expires < now || deadline < now
+}
+
+func (bs *bytesShard) reset() {
+	bs.head, bs.tail, bs.wrapAt, bs.wrapped, bs.count = 0, 0, 0, false, 0
+}
+
+// alloc reserves size contiguous bytes at the tail of the ring, overwriting the oldest entries as needed
+func (bs *bytesShard) alloc(size uint32) uint32 {
+	for {
+		if bs.count == 0 {
+			bs.reset()
+		}
+		if !bs.wrapped {
+			if bs.tail+size <= uint32(len(bs.buf)) {
+				break
+			}
+			bs.wrapAt, bs.tail, bs.wrapped = bs.tail, 0, true
+			continue
+		}
+		if bs.tail+size <= bs.head {
+			break
+		}
+		bs.evictOldest()
+	}
+	pos := bs.tail
+	bs.tail += size
+	bs.count++
+	return pos
+}
+
+func (bs *bytesShard) evictOldest() {
+	entry := bs.buf[bs.head:]
+	hash := binary.LittleEndian.Uint64(entry[0:8])
+	if pos, ok := bs.index[hash]; ok && pos == bs.head {
+		delete(bs.index, hash)
+	}
+	size := bytesHeaderSize + uint32(binary.LittleEndian.Uint16(entry[32:34])) + binary.LittleEndian.Uint32(entry[34:38])
+	bs.head += size
+	bs.count--
+	if bs.wrapped && bs.head >= bs.wrapAt {
+		bs.head, bs.wrapped = 0, false
+	}
+}
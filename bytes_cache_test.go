@@ -0,0 +1,98 @@
+package ttlmap_test
+
+import (
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/packaged/ttlmap"
+)
+
+func TestBytesCacheGet(t *testing.T) {
+	cache := ttlmap.NewBytesCache(ttlmap.WithDefaultTTL(50*time.Millisecond), ttlmap.WithCleanupDuration(10*time.Millisecond))
+	defer cache.Close()
+
+	if _, ok := cache.Get("hello"); ok {
+		t.Fatalf("expected empty cache to return no data")
+	}
+	if err := cache.Set("hello", []byte("world"), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	v, ok := cache.Get("hello")
+	if !ok || string(v) != "world" {
+		t.Fatalf("expected cache to return `world` for `hello`")
+	}
+
+	cache.Remove("hello")
+	if cache.Has("hello") {
+		t.Fatalf("expected removed key to be missing")
+	}
+
+	cache.Set("expires", []byte("soon"), nil)
+	time.Sleep(80 * time.Millisecond)
+	if _, ok = cache.Get("expires"); ok {
+		t.Fatalf("expected item to expire")
+	}
+	if cache.Len() != 0 {
+		t.Fatalf("expected cleanup to clear expired entries")
+	}
+}
+
+func TestBytesCacheRingOverwrite(t *testing.T) {
+	cache := ttlmap.NewBytesCache(ttlmap.WithShardSize(1), ttlmap.WithShardBytes(1024))
+	defer cache.Close()
+
+	if err := cache.Set("big", make([]byte, 1024), nil); err != ttlmap.ErrEntryTooLarge {
+		t.Fatalf("expected ErrEntryTooLarge, got %v", err)
+	}
+
+	value := make([]byte, 100)
+	for i := 0; i < 50; i++ {
+		value[0] = byte(i)
+		if err := cache.Set("k"+strconv.Itoa(i), value, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// The oldest entries have been overwritten, the newest remain intact
+	if cache.Has("k0") {
+		t.Fatalf("expected oldest entry to be overwritten")
+	}
+	for i := 45; i < 50; i++ {
+		v, ok := cache.Get("k" + strconv.Itoa(i))
+		if !ok || v[0] != byte(i) {
+			t.Fatalf("expected recent entry %d to be intact", i)
+		}
+	}
+}
+
+func TestBytesCacheHasher(t *testing.T) {
+	var calls int32
+	cache := ttlmap.NewBytesCache(ttlmap.WithShardSize(3), ttlmap.WithHasher(func(key string) uint64 {
+		atomic.AddInt32(&calls, 1)
+		return uint64(len(key))
+	}))
+	defer cache.Close()
+
+	for _, key := range []string{"a", "bb", "ccc", "dddd"} {
+		if err := cache.Set(key, []byte(key), nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if atomic.LoadInt32(&calls) != 4 {
+		t.Fatalf("expected the hasher to be used")
+	}
+	for _, key := range []string{"a", "bb", "ccc", "dddd"} {
+		if v, ok := cache.Get(key); !ok || string(v) != key {
+			t.Fatalf("expected %s to be stored", key)
+		}
+	}
+	// Keys with the same hash replace each other
+	if err := cache.Set("e", []byte("e"), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cache.Has("a") {
+		t.Fatalf("expected a colliding key to be a miss")
+	}
+}
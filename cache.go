@@ -2,88 +2,119 @@ package ttlmap
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // A "thread" safe map of type string:Interface{}
 // To avoid lock bottlenecks this map is dived to several (SHARD_COUNT) map shards.
 type CacheMap struct {
-	items   []*CacheMapShared
-	options cacheOptions
+	shards   *shardState
+	options  *atomic.Pointer[cacheOptions] // replaced as a whole by Reconfigure
+	stats    *cacheStats
+	deps     *dependencyGraph
+	versions *atomic.Uint64
+	cleanup  *cleanupScheduler
+
+	namespaces *namespaceRegistry
 }
 
 // A "thread" safe string to anything map
 type CacheMapShared struct {
-	shutdown     chan struct{}
-	ticker       *time.Ticker
-	cleanupCycle time.Duration
 	items        map[string]*Item
-	sync.RWMutex // Read Write mutex, guards access to internal map.
+	tags         map[string]map[string]struct{}
+	stats        *cacheStats
+	deps         *dependencyGraph
+	versions     *atomic.Uint64
+	pending      []string // keys removed or replaced whose dependents need invalidating
+	keys         []string // keys in slot order, swept by active expiry
+	sweep        int      // next slot for active expiry to check, counting down
+	migrated     bool     // items have moved to the shards of a new table
+	sync.RWMutex          // Read Write mutex, guards access to internal map.
 }
 
 // Creates a new cache map
 func New(opts ...CacheOption) CacheMap {
 
-	cmp := CacheMap{options: defaultCacheOptions()}
+	cmp := CacheMap{options: &atomic.Pointer[cacheOptions]{}, stats: &cacheStats{}, deps: newDependencyGraph(), versions: &atomic.Uint64{},
+		namespaces: &namespaceRegistry{states: make(map[string]*namespaceState)}}
 
+	options := defaultCacheOptions()
 	for _, opt := range opts {
-		opt(&cmp.options)
+		opt(&options)
 	}
-
-	cmp.items = make([]*CacheMapShared, cmp.options.shardCount)
-	for i := 0; i < cmp.options.shardCount; i++ {
-		cmp.items[i] = &CacheMapShared{items: make(map[string]*Item)}
-		cmp.items[i].initCleanup(cmp.options.cleanupDuration)
+	options.shardCount = powerOfTwo(options.shardCount)
+	if options.hasher == nil {
+		options.hasher = newSeededHasher()
 	}
+	cmp.options.Store(&options)
+
+	cmp.shards = newShardState(options.shardCount, options.hasher, func() *CacheMapShared {
+		return &CacheMapShared{
+			items:    make(map[string]*Item),
+			tags:     make(map[string]map[string]struct{}),
+			stats:    cmp.stats,
+			deps:     cmp.deps,
+			versions: cmp.versions,
+		}
+	})
+	cmp.deps.lock = cmp.lockShard
+	cmp.cleanup = newCleanupScheduler(cmp.shards, &options)
 	return cmp
 }
 
+// Close stops the background cleanup and any resharding in progress, waiting for them to exit
 func (m CacheMap) Close() {
-	for i := 0; i < m.options.shardCount; i++ {
-		m.items[i].Close()
-	}
+	m.cleanup.close()
+	m.shards.close()
 }
 
-// Close stops the cleanup background goroutine and ticker for this shard
-func (ms *CacheMapShared) Close() {
-	// Protect against double-close: close is safe only once; recover if already closed.
-	if ms.shutdown != nil {
-		select {
-		case <-ms.shutdown:
-			// already closed
-		default:
-			close(ms.shutdown)
-		}
-	}
-	if ms.ticker != nil {
-		ms.ticker.Stop()
-	}
-}
+// Close is retained for compatibility, shards no longer run their own cleanup.
+//
+// Deprecated: close the CacheMap instead.
+func (ms *CacheMapShared) Close() {}
 
-// Returns shard under given key
+// Returns shard under given key without locking it.
+// While resharding the key may still be held by an old shard, or be moved once the shard is returned.
 func (m CacheMap) GetShard(key string) *CacheMapShared {
-	return m.items[uint(fnv32(key))%uint(m.options.shardCount)]
+	return m.shards.shard(m.shards.table.Load().shards, key)
 }
 
-func (m CacheMap) MSet(data map[string]interface{}, duration time.Duration) {
-	for key, value := range data {
-		shard := m.GetShard(key)
-		shard.Lock()
-		shard.items[key] = newItem(value, duration, time.Now().Add(m.options.maxLifetime), nil)
-		shard.Unlock()
-	}
+// Write locks and returns the shard holding the key
+func (m CacheMap) lockShard(key string) *CacheMapShared {
+	return m.shards.locate(key, (*CacheMapShared).Lock, (*CacheMapShared).Unlock)
+}
+
+// Read locks and returns the shard holding the key
+func (m CacheMap) rlockShard(key string) *CacheMapShared {
+	return m.shards.locate(key, (*CacheMapShared).RLock, (*CacheMapShared).RUnlock)
 }
 
 func (m CacheMap) SetWithCleanup(key string, value interface{}, duration *time.Duration, cleanup func(*Item)) {
-	// Get map shard.
-	shard := m.GetShard(key)
-	shard.Lock()
-	if duration == nil {
-		duration = &m.options.defaultCacheDuration
+	m.set(key, value, itemOptions{duration: duration, onDelete: cleanup})
+}
+
+// Sets the given value under the specified key, configured with the item options
+func (m CacheMap) SetWithOptions(key string, value interface{}, opts ...ItemOption) {
+	o := itemOptions{}
+	for _, opt := range opts {
+		opt(&o)
 	}
-	itm := newItem(value, *duration, time.Now().Add(m.options.maxLifetime), cleanup)
-	shard.items[key] = itm
-	shard.Unlock()
+	m.set(key, value, o)
+}
+
+func (m CacheMap) set(key string, value interface{}, o itemOptions) {
+	itm := m.newItem(value, o)
+	// Get map shard.
+	shard := m.lockShard(key)
+	shard.set(key, itm)
+	m.grow(shard)
+	shard.unlock()
+}
+
+// Sets the given value under the specified key, expiring at the given time regardless of reads
+func (m CacheMap) SetUntil(key string, value interface{}, until time.Time) {
+	m.set(key, value, itemOptions{until: &until})
 }
 
 // Sets the given value under the specified key
@@ -93,22 +124,22 @@ func (m CacheMap) Set(key string, value interface{}, duration *time.Duration) {
 
 // Retrieves an item from the map with the given key, and optionally increase its expiry time if found
 func (m CacheMap) TouchGet(key string, touch bool) (interface{}, bool) {
-	shard := m.GetShard(key)
-	shard.RLock()
+	shard := m.rlockShard(key)
 	// Get item from shard.
 	val, ok := shard.items[key]
 	var ret interface{}
 	if ok {
-		if val.Expired() {
+		if val.Expired() || !val.access(touch) {
 			ok = false
 		} else {
-			if touch {
-				val.Touch()
-			}
 			ret = val.GetValue()
 		}
 	}
 	shard.RUnlock()
+	m.stats.lookup(ok)
+	if val != nil && val.Expired() {
+		m.evict(key, val)
+	}
 	return ret, ok
 }
 
@@ -119,48 +150,152 @@ func (m CacheMap) Get(key string) (interface{}, bool) {
 
 // Retrieves an item from the map with the given key, and increase its expiry time if found
 func (m CacheMap) GetItem(key string) (*Item, bool) {
-	shard := m.GetShard(key)
-	shard.RLock()
+	shard := m.rlockShard(key)
 	defer shard.RUnlock()
 	if val, ok := shard.items[key]; ok {
-		return &Item{
-			data:     val.data,
-			deadline: val.deadline,
-			ttl:      val.ttl,
-			expires:  val.expires,
-		}, true
+		return val.snapshot(), true
 	}
 	return nil, false
 }
 
 // Removes an element from the map
 func (m CacheMap) Remove(key string) {
-	shard := m.GetShard(key)
-	if shard != nil {
-		shard.Remove(key)
+	shard := m.lockShard(key)
+	shard.remove(key, RemovedDeleted)
+	shard.unlock()
+}
+
+// Removes the item if it is still stored under the key, used to lazily remove expired items found by reads
+func (m CacheMap) evict(key string, itm *Item) {
+	shard := m.lockShard(key)
+	if shard.items[key] == itm {
+		shard.remove(key, itm.expiryReason())
 	}
+	shard.unlock()
 }
 
 // Removes an element from the map
 func (ms *CacheMapShared) Remove(key string) {
 	ms.Lock()
-	ms.remove(key)
-	ms.Unlock()
+	ms.remove(key, RemovedDeleted)
+	ms.unlock()
 }
 
-// Removes an element from the map
-func (ms *CacheMapShared) remove(key string) {
-	if itm, ok := ms.items[key]; ok && itm.onDelete != nil {
+// Removes an element from the map, recording the reason on the item for its removal callback
+func (ms *CacheMapShared) remove(key string, reason RemovalReason) {
+	itm, ok := ms.items[key]
+	if !ok {
+		return
+	}
+	itm.reason = reason
+	if itm.onDelete != nil {
 		itm.onDelete(itm)
 	}
 
+	ms.detach(key, itm)
+	ms.drop(key, itm)
+}
+
+// Stores the item under the key, replacing any existing item
+func (ms *CacheMapShared) set(key string, itm *Item) {
+	if old, ok := ms.items[key]; ok {
+		ms.detach(key, old)
+	}
+	ms.attach(key, itm)
+	ms.store(key, itm)
+}
+
+// Puts the item in the map, taking over the key slot of any item it replaces
+func (ms *CacheMapShared) store(key string, itm *Item) {
+	if old, ok := ms.items[key]; ok {
+		itm.slot = old.slot
+	} else {
+		itm.slot = len(ms.keys)
+		ms.keys = append(ms.keys, key)
+	}
+	ms.items[key] = itm
+}
+
+// Deletes the item from the map, moving the last key into its slot
+func (ms *CacheMapShared) drop(key string, itm *Item) {
+	last := len(ms.keys) - 1
+	if itm.slot != last {
+		moved := ms.keys[last]
+		ms.keys[itm.slot] = moved
+		ms.items[moved].slot = itm.slot
+	}
+	ms.keys[last] = ""
+	ms.keys = ms.keys[:last]
 	delete(ms.items, key)
 }
 
+// Adds the item to the shard indexes and stats
+func (ms *CacheMapShared) attach(key string, itm *Item) {
+	itm.version = ms.nextVersion()
+	ms.stats.track(itm)
+	ms.tag(key, itm)
+	ms.link(key, itm)
+}
+
+// Removes the item from the shard indexes and stats
+func (ms *CacheMapShared) detach(key string, itm *Item) {
+	ms.stats.release(itm)
+	ms.untag(key, itm)
+	ms.unlink(key, itm)
+	if itm.ns != nil {
+		itm.ns.release(itm)
+	}
+}
+
+// Returns the next item version, versions increase across the whole cache
+func (ms *CacheMapShared) nextVersion() uint64 {
+	if ms.versions == nil {
+		return 0
+	}
+	return ms.versions.Add(1)
+}
+
+// Creates a new item using the cache defaults, compressing the value when configured
+func (m CacheMap) newItem(value interface{}, o itemOptions) *Item {
+	options := m.options.Load()
+	duration := options.defaultCacheDuration
+	if o.duration != nil {
+		duration = *o.duration
+	}
+	maxLifetime := options.maxLifetime
+	if o.maxLifetime != nil {
+		maxLifetime = *o.maxLifetime
+	}
+	deadline := time.Now().Add(maxLifetime)
+	if o.deadline != nil {
+		deadline = *o.deadline
+	}
+	if o.until != nil {
+		duration = time.Until(*o.until)
+		if o.until.Before(deadline) {
+			deadline = *o.until
+		}
+	}
+	itm := newItem(m.compress(value), duration, deadline, o.onDelete)
+	itm.fixed = o.fixed
+	itm.defaultLifetime = o.maxLifetime == nil && o.until == nil && o.deadline == nil
+	itm.pinned = o.pinned
+	itm.maxReads = o.maxReads
+	itm.maxIdle = o.maxIdle
+	if o.until != nil {
+		until := *o.until
+		itm.expires = &until
+		itm.fixed = true
+	}
+	itm.tags = o.tags
+	itm.dependsOn = o.dependsOn
+	itm.cost = o.cost
+	return itm
+}
+
 // Has checks to see if an item exists
 func (m CacheMap) Has(key string) bool {
-	shard := m.GetShard(key)
-	shard.RLock()
+	shard := m.rlockShard(key)
 	val, ok := shard.items[key]
 	if ok && val.Expired() {
 		ok = false
@@ -170,8 +305,7 @@ func (m CacheMap) Has(key string) bool {
 }
 
 func (m CacheMap) GetExpiry(key string) *time.Time {
-	shard := m.GetShard(key)
-	shard.RLock()
+	shard := m.rlockShard(key)
 	var expiry *time.Time
 	val, ok := shard.items[key]
 	if ok {
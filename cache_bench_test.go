@@ -2,6 +2,7 @@ package ttlmap_test
 
 import (
 	"math/rand"
+	"runtime"
 	"strconv"
 	"testing"
 	"time"
@@ -92,3 +93,78 @@ func BenchmarkMixedRW(b *testing.B) {
 		}
 	})
 }
+
+func BenchmarkBytesCacheSet(b *testing.B) {
+	cache := ttlmap.NewBytesCache(ttlmap.WithCleanupDuration(time.Hour))
+	defer cache.Close()
+	value := make([]byte, 64)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cache.Set("key"+strconv.Itoa(i), value, nil)
+	}
+}
+
+func BenchmarkBytesCacheGetHit(b *testing.B) {
+	cache := ttlmap.NewBytesCache(ttlmap.WithCleanupDuration(time.Hour))
+	defer cache.Close()
+	keys := prepopulateBytes(cache, 1024)
+	b.ResetTimer()
+	idx := 0
+	for i := 0; i < b.N; i++ {
+		if idx == len(keys) {
+			idx = 0
+		}
+		cache.Get(keys[idx])
+		idx++
+	}
+}
+
+func BenchmarkParallelBytesCacheGetHit(b *testing.B) {
+	cache := ttlmap.NewBytesCache(ttlmap.WithCleanupDuration(time.Hour))
+	defer cache.Close()
+	keys := prepopulateBytes(cache, 4096)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		r := rand.New(rand.NewSource(time.Now().UnixNano()))
+		for pb.Next() {
+			cache.Get(keys[r.Intn(len(keys))])
+		}
+	})
+}
+
+// GC benchmarks compare the mark cost of a large CacheMap against a BytesCache holding the same data
+func BenchmarkGCCacheMap(b *testing.B) {
+	cache := ttlmap.New(ttlmap.WithCleanupDuration(time.Hour))
+	defer cache.Close()
+	for i := 0; i < 200000; i++ {
+		cache.Set("k"+strconv.Itoa(i), make([]byte, 64), nil)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		runtime.GC()
+	}
+	runtime.KeepAlive(cache)
+}
+
+func BenchmarkGCBytesCache(b *testing.B) {
+	cache := ttlmap.NewBytesCache(ttlmap.WithCleanupDuration(time.Hour), ttlmap.WithShardBytes(1<<20))
+	defer cache.Close()
+	prepopulateBytes(cache, 200000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		runtime.GC()
+	}
+	runtime.KeepAlive(cache)
+}
+
+// helper to prepopulate a bytes cache with n keys
+func prepopulateBytes(c ttlmap.BytesCache, n int) []string {
+	keys := make([]string, n)
+	value := make([]byte, 64)
+	for i := 0; i < n; i++ {
+		k := "k" + strconv.Itoa(i)
+		c.Set(k, value, nil)
+		keys[i] = k
+	}
+	return keys
+}
@@ -1,17 +1,36 @@
 package ttlmap
 
-import "time"
+import (
+	"sync"
+	"time"
+)
 
 func (m CacheMap) Flush() {
-	for i := 0; i < m.options.shardCount; i++ {
-		m.items[i].Flush()
+	t := m.shards.pin()
+	defer m.shards.unpin()
+	for _, shard := range t.all {
+		shard.Flush()
 	}
 }
 
 func (ms *CacheMapShared) Flush() {
 	ms.Lock()
+	for key, itm := range ms.items {
+		ms.detach(key, itm)
+	}
 	ms.items = make(map[string]*Item)
-	ms.Unlock()
+	ms.tags = make(map[string]map[string]struct{})
+	ms.keys = nil
+	ms.unlock()
+}
+
+// Cleanup removes any expired items from every shard
+func (m CacheMap) Cleanup() {
+	t := m.shards.pin()
+	defer m.shards.unpin()
+	for _, shard := range t.all {
+		shard.Cleanup()
+	}
 }
 
 // Cleanup removes any expired items from the cache map
@@ -19,27 +38,125 @@ func (ms *CacheMapShared) Cleanup() {
 	ms.Lock()
 	for key, item := range ms.items {
 		if item.Expired() {
-			ms.remove(key)
+			ms.remove(key, item.expiryReason())
+		}
+	}
+	ms.unlock()
+}
+
+// expiryBatchSize is the number of items checked each time active expiry takes the shard lock
+const expiryBatchSize = 20
+
+// expire sweeps the shard for expired items in batches, releasing the write lock between each.
+// The sweep continues from where the previous call stopped until every item has been checked once, or the
+// deadline passes, returning true once the pass is complete. Walking the slots downwards means a removal only
+// moves an already checked key.
+func (ms *CacheMapShared) expire(deadline time.Time) bool {
+	ms.Lock()
+	if ms.sweep <= 0 {
+		// Begin a new pass over the shard
+		ms.sweep = len(ms.keys)
+	}
+	ms.unlock()
+
+	for {
+		ms.Lock()
+		if ms.sweep > len(ms.keys) {
+			ms.sweep = len(ms.keys)
+		}
+		for i := 0; i < expiryBatchSize && ms.sweep > 0; i++ {
+			ms.sweep--
+			key := ms.keys[ms.sweep]
+			if item := ms.items[key]; item.Expired() {
+				ms.remove(key, item.expiryReason())
+			}
+		}
+		done := ms.sweep == 0
+		ms.unlock()
+		if done || !time.Now().Before(deadline) {
+			return done
 		}
 	}
-	ms.Unlock()
 }
 
-func (ms *CacheMapShared) initCleanup(dur time.Duration) {
-	// Initialize shutdown channel once
-	if ms.shutdown == nil {
-		ms.shutdown = make(chan struct{})
+// cleanupScheduler runs active expiry for every shard of a cache from a single goroutine.
+// Its mutex also serialises Reconfigure.
+type cleanupScheduler struct {
+	sync.Mutex
+	shards   *shardState
+	shutdown chan struct{} // nil when the goroutine is not running
+	done     chan struct{}
+	closed   bool
+}
+
+func newCleanupScheduler(shards *shardState, o *cacheOptions) *cleanupScheduler {
+	s := &cleanupScheduler{shards: shards}
+	s.start(o)
+	return s
+}
+
+// start runs the cleanup goroutine when enabled, the scheduler must be locked or not yet shared
+func (s *cleanupScheduler) start(o *cacheOptions) {
+	if s.closed || !o.backgroundCleanup {
+		return
 	}
-	// Use NewTicker so we can Stop it later to avoid ticker leaks
-	ms.ticker = time.NewTicker(dur)
-	go func() {
-		for {
+	s.shutdown, s.done = make(chan struct{}), make(chan struct{})
+	go s.run(s.shutdown, s.done, o.cleanupDuration, o.expiryBudget)
+}
+
+// run sweeps the shards in turn, spending at most the budget each tick and resuming at the shard it stopped on
+func (s *cleanupScheduler) run(shutdown, done chan struct{}, dur, budget time.Duration) {
+	defer close(done)
+	ticker := time.NewTicker(dur)
+	defer ticker.Stop()
+	next := 0
+	for {
+		select {
+		case <-shutdown:
+			return
+		case <-ticker.C:
+		}
+		deadline := time.Now().Add(budget)
+		shards := s.shards.table.Load().all
+		// Visit each shard at most once per tick, checking for shutdown between each.
+		// At least one batch is swept every tick, however small the budget.
+		for i := 0; i < len(shards) && (i == 0 || time.Now().Before(deadline)); i++ {
 			select {
-			case <-ms.shutdown:
+			case <-shutdown:
 				return
-			case <-ms.ticker.C:
-				ms.Cleanup()
+			default:
+			}
+			if next >= len(shards) {
+				next = 0
+			}
+			if !shards[next].expire(deadline) {
+				break
 			}
+			next++
 		}
-	}()
+	}
+}
+
+// stop signals the cleanup goroutine to exit and waits for it to do so, the scheduler must be locked
+func (s *cleanupScheduler) stop() {
+	if s.shutdown == nil {
+		return
+	}
+	close(s.shutdown)
+	<-s.done
+	s.shutdown, s.done = nil, nil
+}
+
+// restart applies new options to the cleanup goroutine, the scheduler must be locked
+func (s *cleanupScheduler) restart(o *cacheOptions) {
+	s.stop()
+	s.start(o)
+}
+
+// close stops the cleanup goroutine for good, it is safe to call more than once
+func (s *cleanupScheduler) close() {
+	s.Lock()
+	defer s.Unlock()
+	s.closed = true
+	s.stop()
 }
@@ -0,0 +1,211 @@
+package ttlmap_test
+
+import (
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/packaged/ttlmap"
+)
+
+func TestActiveExpiry(t *testing.T) {
+	cache := ttlmap.New(ttlmap.WithShardSize(1), ttlmap.WithCleanupDuration(10*time.Millisecond))
+	defer cache.Close()
+
+	short := 5 * time.Millisecond
+	for i := 0; i < 2000; i++ {
+		cache.Set("short"+strconv.Itoa(i), i, &short)
+	}
+	for i := 0; i < 100; i++ {
+		cache.Set("long"+strconv.Itoa(i), i, nil)
+	}
+
+	countStale := func() int {
+		stale := 0
+		for i := 0; i < 2000; i++ {
+			if cache.GetWithStatus("short"+strconv.Itoa(i)).Freshness != ttlmap.Missing {
+				stale++
+			}
+		}
+		return stale
+	}
+
+	// Each tick sweeps the shard within the time budget, so few expired items remain
+	time.Sleep(100 * time.Millisecond)
+	if stale := countStale(); stale > 200 {
+		t.Fatalf("expected active expiry to remove most expired items, %d remain", stale)
+	}
+
+	// A full cleanup removes everything expired
+	cache.GetShard("any").Cleanup()
+	if stale := countStale(); stale != 0 {
+		t.Fatalf("expected cleanup to remove all expired items, %d remain", stale)
+	}
+	if n := cache.Len(); n != 100 {
+		t.Fatalf("expected 100 live items, got %d", n)
+	}
+}
+
+func TestLazyExpiryOnGet(t *testing.T) {
+	cache := ttlmap.New(ttlmap.WithCleanupDuration(time.Hour))
+	defer cache.Close()
+
+	removed := 0
+	short := time.Millisecond
+	cache.SetWithCleanup("k", 1, &short, func(item *ttlmap.Item) {
+		if item.RemovalReason() == ttlmap.RemovedExpired {
+			removed++
+		}
+	})
+	time.Sleep(5 * time.Millisecond)
+
+	if _, ok := cache.Get("k"); ok {
+		t.Fatalf("expected expired item to be missing")
+	}
+	if removed != 1 {
+		t.Fatalf("expected expired item to be removed by the read")
+	}
+}
+
+func TestCloseStopsCleanup(t *testing.T) {
+	// Wait for goroutines left by earlier tests to settle
+	settle := func(want int) int {
+		n := runtime.NumGoroutine()
+		for i := 0; i < 100 && n > want; i++ {
+			time.Sleep(time.Millisecond)
+			n = runtime.NumGoroutine()
+		}
+		return n
+	}
+	before := settle(0)
+
+	caches := make([]ttlmap.CacheMap, 10)
+	for i := range caches {
+		caches[i] = ttlmap.New(ttlmap.WithCleanupDuration(time.Millisecond))
+	}
+	if n := runtime.NumGoroutine(); n > before+len(caches) {
+		t.Fatalf("expected a single cleanup goroutine per cache, %d running", n-before)
+	}
+	time.Sleep(5 * time.Millisecond)
+	for _, cache := range caches {
+		cache.Close()
+		cache.Close()
+	}
+	if n := settle(before); n > before {
+		t.Fatalf("expected Close to stop every goroutine, %d still running", n-before)
+	}
+
+	lazy := ttlmap.New(ttlmap.WithoutBackgroundCleanup())
+	defer lazy.Close()
+	if n := runtime.NumGoroutine(); n > before {
+		t.Fatalf("expected no goroutines without background cleanup, %d running", n-before)
+	}
+}
+
+func TestWithoutBackgroundCleanup(t *testing.T) {
+	cache := ttlmap.New(ttlmap.WithoutBackgroundCleanup())
+	defer cache.Close()
+
+	removed := 0
+	cleanup := func(item *ttlmap.Item) { removed++ }
+	short := time.Millisecond
+	cache.SetWithCleanup("a", 1, &short, cleanup)
+	cache.SetWithCleanup("b", 2, &short, cleanup)
+	cache.SetWithCleanup("c", 3, nil, cleanup)
+	time.Sleep(5 * time.Millisecond)
+
+	if removed != 0 {
+		t.Fatalf("expected expired items to remain until read or cleanup")
+	}
+	if _, ok := cache.Get("a"); ok {
+		t.Fatalf("expected expired item to be missing")
+	}
+	if removed != 1 {
+		t.Fatalf("expected read to remove the expired item")
+	}
+	cache.Cleanup()
+	if removed != 2 || cache.Len() != 1 {
+		t.Fatalf("expected cleanup to remove the expired item")
+	}
+}
+
+func TestActiveExpiryReclaimsFewExpired(t *testing.T) {
+	cache := ttlmap.New(ttlmap.WithShardSize(1), ttlmap.WithCleanupDuration(10*time.Millisecond))
+	defer cache.Close()
+
+	var removed int32
+	for i := 0; i < 10000; i++ {
+		cache.Set("live"+strconv.Itoa(i), i, nil)
+	}
+	short := time.Millisecond
+	for i := 0; i < 1000; i++ {
+		cache.SetWithCleanup("short"+strconv.Itoa(i), i, &short, func(item *ttlmap.Item) {
+			atomic.AddInt32(&removed, 1)
+		})
+	}
+
+	// A mostly live shard is still swept, so expired items that are never read are reclaimed
+	deadline := time.Now().Add(2 * time.Second)
+	for atomic.LoadInt32(&removed) < 1000 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected active expiry to reclaim every expired item, %d of 1000 removed", atomic.LoadInt32(&removed))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := cache.Len(); n != 10000 {
+		t.Fatalf("expected 10000 live items, got %d", n)
+	}
+}
+
+func TestExpiryBudgetPerTick(t *testing.T) {
+	budget := 10 * time.Millisecond
+	cache := ttlmap.New(ttlmap.WithShardSize(64), ttlmap.WithCleanupDuration(100*time.Millisecond), ttlmap.WithExpiryBudget(budget))
+	defer cache.Close()
+
+	var mu sync.Mutex
+	var removed []time.Time
+	short := time.Millisecond
+	for i := 0; i < 32; i++ {
+		cache.SetWithCleanup("k"+strconv.Itoa(i), i, &short, func(item *ttlmap.Item) {
+			time.Sleep(2 * time.Millisecond)
+			mu.Lock()
+			removed = append(removed, time.Now())
+			mu.Unlock()
+		})
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		n := len(removed)
+		mu.Unlock()
+		if n == 32 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected active expiry to reclaim every item, %d of 32 removed", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Removals within a tick are close together, ticks are far apart. The sweep of every shard together stays
+	// within the budget, overrunning by at most the shard being swept when it ran out.
+	mu.Lock()
+	defer mu.Unlock()
+	ticks, start := 1, removed[0]
+	for i := 1; i < len(removed); i++ {
+		if removed[i].Sub(removed[i-1]) > 50*time.Millisecond {
+			ticks, start = ticks+1, removed[i]
+			continue
+		}
+		if span := removed[i].Sub(start); span > 3*budget {
+			t.Fatalf("expected each tick to stay within the budget, swept for %v", span)
+		}
+	}
+	if ticks < 2 {
+		t.Fatalf("expected the sweep to resume on later ticks")
+	}
+}
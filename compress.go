@@ -0,0 +1,118 @@
+package ttlmap
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+)
+
+// Codec compresses and decompresses cached values
+type Codec interface {
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+// GzipCodec compresses values using gzip
+type GzipCodec struct{}
+
+// Compress compresses data with gzip
+func (GzipCodec) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress decompresses gzip data
+func (GzipCodec) Decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// FlateCodec compresses values using raw deflate
+type FlateCodec struct{}
+
+// Compress compresses data with deflate
+func (FlateCodec) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(data); err != nil {
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress decompresses deflate data
+func (FlateCodec) Decompress(data []byte) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(data))
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// compressedValue holds a compressed []byte or string value
+type compressedValue struct {
+	codec    Codec
+	data     []byte
+	rawSize  int
+	isString bool
+}
+
+// value decompresses back to the original type, returning nil if the data can not be decompressed
+func (cv *compressedValue) value() interface{} {
+	raw, err := cv.codec.Decompress(cv.data)
+	if err != nil {
+		return nil
+	}
+	if cv.isString {
+		return string(raw)
+	}
+	return raw
+}
+
+// compress wraps large []byte and string values in a compressedValue, values which do not shrink are stored as is
+func (m CacheMap) compress(value interface{}) interface{} {
+	o := m.options.Load()
+	if o.codec == nil {
+		return value
+	}
+
+	var raw []byte
+	isString := false
+	switch v := value.(type) {
+	case []byte:
+		if len(v) < o.compressThreshold {
+			return value
+		}
+		raw = v
+	case string:
+		if len(v) < o.compressThreshold {
+			return value
+		}
+		raw = []byte(v)
+		isString = true
+	default:
+		return value
+	}
+
+	data, err := o.codec.Compress(raw)
+	if err != nil || len(data) >= len(raw) {
+		return value
+	}
+	return &compressedValue{codec: o.codec, data: data, rawSize: len(raw), isString: isString}
+}
@@ -0,0 +1,47 @@
+package ttlmap_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/packaged/ttlmap"
+)
+
+func TestCompression(t *testing.T) {
+	for _, codec := range []ttlmap.Codec{ttlmap.GzipCodec{}, ttlmap.FlateCodec{}} {
+		cache := ttlmap.New(ttlmap.WithCompression(64, codec))
+		blob := strings.Repeat(`{"name":"value"}`, 100)
+
+		cache.Set("small", "tiny", nil)
+		cache.Set("string", blob, nil)
+		cache.Set("bytes", []byte(blob), nil)
+
+		v, ok := cache.Get("string")
+		if !ok || v.(string) != blob {
+			t.Fatalf("expected string value to decompress")
+		}
+		v, ok = cache.Get("bytes")
+		if !ok || string(v.([]byte)) != blob {
+			t.Fatalf("expected []byte value to decompress")
+		}
+		s, err := ttlmap.Fetch[string](cache, "string", func(string) (string, error) { return "", nil })
+		if err != nil || s != blob {
+			t.Fatalf("expected fetch to return decompressed value")
+		}
+
+		stats := cache.Stats()
+		if stats.CompressedItems != 2 || stats.RawBytes != int64(2*len(blob)) {
+			t.Fatalf("unexpected stats %+v", stats)
+		}
+		if stats.CompressedBytes <= 0 || stats.CompressedBytes >= stats.RawBytes {
+			t.Fatalf("expected compressed bytes to be smaller than raw bytes, %+v", stats)
+		}
+
+		cache.Remove("string")
+		cache.Flush()
+		if stats = cache.Stats(); stats.CompressedItems != 0 || stats.RawBytes != 0 || stats.CompressedBytes != 0 {
+			t.Fatalf("expected compression stats to be released, %+v", stats)
+		}
+		cache.Close()
+	}
+}
@@ -0,0 +1,62 @@
+package ttlmap
+
+import "time"
+
+// Action is the outcome of a Compute function
+type Action int
+
+const (
+	// ActionKeep leaves the item unchanged
+	ActionKeep Action = iota
+	// ActionReplace stores the new value, creating the item if it does not exist
+	ActionReplace
+	// ActionDelete removes the item
+	ActionDelete
+	// ActionTouch increases the expiry time of the item
+	ActionTouch
+)
+
+// Compute runs fn under the key's shard lock, letting it keep, replace, delete or touch the item in one atomic step.
+// fn receives a copy of the unexpired item, or exists false when missing or expired, and must not call back into the cache.
+// A ttl of zero uses the cache default when creating an item, and the item TTL when replacing or touching.
+// Compute returns the value held after the action, and whether the item exists.
+func (m CacheMap) Compute(key string, fn func(old *Item, exists bool) (newValue interface{}, ttl time.Duration, action Action)) (interface{}, bool) {
+	shard := m.lockShard(key)
+	defer shard.unlock()
+
+	itm, exists := shard.live(key)
+	var old *Item
+	if exists {
+		old = itm.snapshot()
+	}
+	value, ttl, action := fn(old, exists)
+
+	switch action {
+	case ActionReplace:
+		if !exists {
+			o := itemOptions{}
+			if ttl > 0 {
+				o.duration = &ttl
+			}
+			shard.set(key, m.newItem(value, o))
+			m.grow(shard)
+			return value, true
+		}
+		shard.update(key, itm, m.compress(value))
+		itm.touch(ttl)
+		return value, true
+	case ActionDelete:
+		if exists {
+			shard.remove(key, RemovedDeleted)
+		}
+		return nil, false
+	case ActionTouch:
+		if exists {
+			itm.touch(ttl)
+		}
+	}
+	if !exists {
+		return nil, false
+	}
+	return itm.GetValue(), true
+}
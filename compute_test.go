@@ -0,0 +1,67 @@
+package ttlmap_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/packaged/ttlmap"
+)
+
+func TestCompute(t *testing.T) {
+	cache := ttlmap.New()
+	defer cache.Close()
+
+	appendValue := func(old *ttlmap.Item, exists bool) (interface{}, time.Duration, ttlmap.Action) {
+		if !exists {
+			return []int{1}, time.Minute, ttlmap.ActionReplace
+		}
+		values := old.GetValue().([]int)
+		return append(append([]int{}, values...), len(values)+1), 0, ttlmap.ActionReplace
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cache.Compute("list", appendValue)
+		}()
+	}
+	wg.Wait()
+
+	v, ok := cache.Get("list")
+	if !ok || len(v.([]int)) != 20 {
+		t.Fatalf("expected 20 atomic appends, got %v", v)
+	}
+
+	short := 10 * time.Millisecond
+	cache.Set("touch", 1, &short)
+	v, ok = cache.Compute("touch", func(old *ttlmap.Item, exists bool) (interface{}, time.Duration, ttlmap.Action) {
+		return nil, time.Minute, ttlmap.ActionTouch
+	})
+	if !ok || v != 1 {
+		t.Fatalf("expected touch to keep the value")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if !cache.Has("touch") {
+		t.Fatalf("expected touch to extend the expiry")
+	}
+
+	_, ok = cache.Compute("touch", func(old *ttlmap.Item, exists bool) (interface{}, time.Duration, ttlmap.Action) {
+		return nil, 0, ttlmap.ActionDelete
+	})
+	if ok || cache.Has("touch") {
+		t.Fatalf("expected delete to remove the item")
+	}
+
+	_, ok = cache.Compute("missing", func(old *ttlmap.Item, exists bool) (interface{}, time.Duration, ttlmap.Action) {
+		if exists || old != nil {
+			t.Fatalf("expected missing item")
+		}
+		return nil, 0, ttlmap.ActionKeep
+	})
+	if ok || cache.Has("missing") {
+		t.Fatalf("expected keep on a missing item to store nothing")
+	}
+}
@@ -0,0 +1,132 @@
+package ttlmap
+
+import (
+	"bytes"
+	"reflect"
+	"time"
+)
+
+// GetOrSet returns the existing value for the key if present, otherwise it stores and returns the given value.
+// The loaded result is true if the value was loaded, false if stored.
+func (m CacheMap) GetOrSet(key string, value interface{}, duration *time.Duration) (actual interface{}, loaded bool) {
+	return m.getOrSet(key, value, itemOptions{duration: duration})
+}
+
+func (m CacheMap) getOrSet(key string, value interface{}, o itemOptions) (interface{}, bool) {
+	itm := m.newItem(value, o)
+	shard := m.lockShard(key)
+	defer shard.unlock()
+	if existing, ok := shard.live(key); ok {
+		return existing.GetValue(), true
+	}
+	shard.set(key, itm)
+	m.grow(shard)
+	return value, false
+}
+
+// SetIfAbsent stores the value only if the key is not present, returning true if stored
+func (m CacheMap) SetIfAbsent(key string, value interface{}, duration *time.Duration) bool {
+	_, loaded := m.GetOrSet(key, value, duration)
+	return !loaded
+}
+
+// SetIfPresent replaces the value only if the key is present, returning true if replaced
+func (m CacheMap) SetIfPresent(key string, value interface{}, duration *time.Duration) bool {
+	itm := m.newItem(value, itemOptions{duration: duration})
+	shard := m.lockShard(key)
+	defer shard.unlock()
+	if _, ok := shard.live(key); !ok {
+		return false
+	}
+	shard.set(key, itm)
+	m.grow(shard)
+	return true
+}
+
+// CompareAndSwap replaces the value only if the current value is equal to old, keeping the item expiry.
+// A nil equal function compares the values with ==, or by content for []byte and other uncomparable values.
+func (m CacheMap) CompareAndSwap(key string, old, new interface{}, equal func(a, b interface{}) bool) bool {
+	if equal == nil {
+		equal = valuesEqual
+	}
+	data := m.compress(new)
+	shard := m.lockShard(key)
+	defer shard.unlock()
+	itm, ok := shard.live(key)
+	if !ok || !equal(itm.GetValue(), old) {
+		return false
+	}
+	shard.update(key, itm, data)
+	return true
+}
+
+// CompareAndDelete removes the item only if the current value is equal to old.
+// A nil equal function compares the values with ==, or by content for []byte and other uncomparable values.
+func (m CacheMap) CompareAndDelete(key string, old interface{}, equal func(a, b interface{}) bool) bool {
+	if equal == nil {
+		equal = valuesEqual
+	}
+	shard := m.lockShard(key)
+	defer shard.unlock()
+	itm, ok := shard.live(key)
+	if !ok || !equal(itm.GetValue(), old) {
+		return false
+	}
+	shard.remove(key, RemovedDeleted)
+	return true
+}
+
+// GetAndDelete removes the item, returning its value if it was present
+func (m CacheMap) GetAndDelete(key string) (interface{}, bool) {
+	shard := m.lockShard(key)
+	defer shard.unlock()
+	itm, ok := shard.live(key)
+	if !ok {
+		return nil, false
+	}
+	shard.remove(key, RemovedDeleted)
+	return itm.GetValue(), true
+}
+
+// Returns the unexpired item for the key, expired items are removed.
+// The shard write lock must be held.
+func (ms *CacheMapShared) live(key string) (*Item, bool) {
+	itm, ok := ms.items[key]
+	if !ok {
+		return nil, false
+	}
+	if itm.Expired() {
+		ms.remove(key, itm.expiryReason())
+		return nil, false
+	}
+	return itm, true
+}
+
+// Replaces the data of a stored item in place, keeping its expiry and settings
+func (ms *CacheMapShared) update(key string, itm *Item, data interface{}) {
+	ms.stats.release(itm)
+	itm.data = data
+	itm.version = ms.nextVersion()
+	itm.updated = time.Now()
+	ms.stats.track(itm)
+	ms.changed(key)
+}
+
+// valuesEqual compares with ==, using bytes.Equal for []byte and reflect.DeepEqual for other uncomparable values
+func valuesEqual(a, b interface{}) bool {
+	if ab, ok := a.([]byte); ok {
+		bb, ok := b.([]byte)
+		return ok && bytes.Equal(ab, bb)
+	}
+	if a == nil || b == nil {
+		return a == b
+	}
+	t := reflect.TypeOf(a)
+	if t != reflect.TypeOf(b) {
+		return false
+	}
+	if t.Comparable() {
+		return a == b
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,99 @@
+package ttlmap_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/packaged/ttlmap"
+)
+
+func TestGetOrSet(t *testing.T) {
+	cache := ttlmap.New()
+	defer cache.Close()
+
+	var stored int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, loaded := cache.GetOrSet("k", i, nil); !loaded {
+				atomic.AddInt32(&stored, 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if stored != 1 {
+		t.Fatalf("expected a single store, got %d", stored)
+	}
+
+	expired := time.Millisecond
+	cache.Set("expired", 1, &expired)
+	time.Sleep(5 * time.Millisecond)
+	if cache.SetIfPresent("expired", 2, nil) {
+		t.Fatalf("expected expired item to count as absent")
+	}
+	if !cache.SetIfAbsent("expired", 3, nil) || cache.SetIfAbsent("expired", 4, nil) {
+		t.Fatalf("expected SetIfAbsent to store once")
+	}
+	if !cache.SetIfPresent("expired", 5, nil) {
+		t.Fatalf("expected SetIfPresent to replace")
+	}
+	if v, _ := cache.Get("expired"); v != 5 {
+		t.Fatalf("expected 5, got %v", v)
+	}
+}
+
+func TestCompareAndSwap(t *testing.T) {
+	cache := ttlmap.New()
+	defer cache.Close()
+
+	ttl := time.Minute
+	cache.Set("k", []int{1}, &ttl)
+	expiry := cache.GetExpiry("k")
+	sameFirst := func(a, b interface{}) bool { return a.([]int)[0] == b.([]int)[0] }
+
+	if cache.CompareAndSwap("k", []int{2}, []int{3}, sameFirst) {
+		t.Fatalf("expected swap to fail on mismatch")
+	}
+	if !cache.CompareAndSwap("k", []int{1}, []int{3}, sameFirst) {
+		t.Fatalf("expected swap to succeed")
+	}
+	if !cache.GetExpiry("k").Equal(*expiry) {
+		t.Fatalf("expected swap to keep the item expiry")
+	}
+	if cache.CompareAndDelete("k", []int{1}, sameFirst) || !cache.CompareAndDelete("k", []int{3}, sameFirst) {
+		t.Fatalf("expected delete to only succeed on a match")
+	}
+
+	cache.Set("pop", "v", nil)
+	if v, ok := cache.GetAndDelete("pop"); !ok || v != "v" || cache.Has("pop") {
+		t.Fatalf("expected GetAndDelete to return and remove the value")
+	}
+	if _, ok := cache.GetAndDelete("pop"); ok {
+		t.Fatalf("expected missing key to return false")
+	}
+}
+
+func TestCompareAndSwapBytes(t *testing.T) {
+	cache := ttlmap.New()
+	defer cache.Close()
+
+	cache.Set("k", []byte("a"), nil)
+	if cache.CompareAndSwap("k", []byte("x"), []byte("b"), nil) {
+		t.Fatalf("expected swap to fail on mismatch")
+	}
+	if !cache.CompareAndSwap("k", []byte("a"), []byte("b"), nil) {
+		t.Fatalf("expected []byte values to be compared by content")
+	}
+	if cache.CompareAndDelete("k", "b", nil) || !cache.CompareAndDelete("k", []byte("b"), nil) {
+		t.Fatalf("expected delete to only succeed on a matching []byte")
+	}
+
+	cache.Set("m", map[string]int{"a": 1}, nil)
+	if !cache.CompareAndSwap("m", map[string]int{"a": 1}, 2, nil) {
+		t.Fatalf("expected uncomparable values to be compared by content")
+	}
+}
@@ -0,0 +1,48 @@
+package ttlmap
+
+import "time"
+
+// AddInt64 atomically adds delta to the int64 stored under the key, returning the new value.
+// A missing or expired key is created with the duration, otherwise the original expiry is kept unless touch is true.
+func (m CacheMap) AddInt64(key string, delta int64, duration *time.Duration, touch bool) (int64, error) {
+	return add(m, key, delta, duration, touch)
+}
+
+// AddFloat64 atomically adds delta to the float64 stored under the key, returning the new value.
+// A missing or expired key is created with the duration, otherwise the original expiry is kept unless touch is true.
+func (m CacheMap) AddFloat64(key string, delta float64, duration *time.Duration, touch bool) (float64, error) {
+	return add(m, key, delta, duration, touch)
+}
+
+// Increment atomically adds one to the int64 stored under the key, returning the new value
+func (m CacheMap) Increment(key string, duration *time.Duration) (int64, error) {
+	return add(m, key, int64(1), duration, false)
+}
+
+// Decrement atomically subtracts one from the int64 stored under the key, returning the new value
+func (m CacheMap) Decrement(key string, duration *time.Duration) (int64, error) {
+	return add(m, key, int64(-1), duration, false)
+}
+
+func add[T int64 | float64](m CacheMap, key string, delta T, duration *time.Duration, touch bool) (T, error) {
+	shard := m.lockShard(key)
+	defer shard.unlock()
+
+	itm, ok := shard.live(key)
+	if !ok {
+		shard.set(key, m.newItem(delta, itemOptions{duration: duration}))
+		m.grow(shard)
+		return delta, nil
+	}
+	value, ok := itm.GetValue().(T)
+	if !ok {
+		var zero T
+		return zero, ErrTypeMismatch
+	}
+	value += delta
+	shard.update(key, itm, value)
+	if touch {
+		itm.Touch()
+	}
+	return value, nil
+}
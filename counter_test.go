@@ -0,0 +1,57 @@
+package ttlmap_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/packaged/ttlmap"
+)
+
+func TestCounters(t *testing.T) {
+	cache := ttlmap.New()
+	defer cache.Close()
+
+	ttl := 50 * time.Millisecond
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cache.Increment("hits", &ttl)
+		}()
+	}
+	wg.Wait()
+	expiry := cache.GetExpiry("hits")
+
+	v, err := cache.Decrement("hits", &ttl)
+	if err != nil || v != 99 {
+		t.Fatalf("expected 99, got %d (%v)", v, err)
+	}
+	if !cache.GetExpiry("hits").Equal(*expiry) {
+		t.Fatalf("expected increments to keep the original expiry")
+	}
+	if _, err = cache.AddInt64("hits", 1, &ttl, true); err != nil || cache.GetExpiry("hits").Equal(*expiry) {
+		t.Fatalf("expected touch to extend the expiry")
+	}
+
+	// Expired counters start again
+	time.Sleep(60 * time.Millisecond)
+	if v, _ = cache.AddInt64("hits", 5, &ttl, false); v != 5 {
+		t.Fatalf("expected expired counter to restart at 5, got %d", v)
+	}
+
+	cache.AddFloat64("rate", 0.5, nil, false)
+	f, err := cache.AddFloat64("rate", 0.25, nil, false)
+	if err != nil || f != 0.75 {
+		t.Fatalf("expected 0.75, got %f (%v)", f, err)
+	}
+
+	cache.Set("str", "x", nil)
+	if _, err = cache.Increment("str", nil); err != ttlmap.ErrTypeMismatch {
+		t.Fatalf("expected ErrTypeMismatch, got %v", err)
+	}
+	if _, err = cache.AddFloat64("hits", 1, nil, false); err != ttlmap.ErrTypeMismatch {
+		t.Fatalf("expected ErrTypeMismatch, got %v", err)
+	}
+}
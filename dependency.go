@@ -0,0 +1,126 @@
+package ttlmap
+
+import "sync"
+
+// dependencyGraph tracks which keys depend on each other across all shards
+type dependencyGraph struct {
+	sync.Mutex
+	dependents map[string]map[string]struct{}   // parent key to dependent keys
+	lock       func(key string) *CacheMapShared // write locks the shard holding the key
+}
+
+func newDependencyGraph() *dependencyGraph {
+	return &dependencyGraph{dependents: make(map[string]map[string]struct{})}
+}
+
+// Dependents returns the keys which directly depend on the given key
+func (m CacheMap) Dependents(key string) []string {
+	return m.deps.direct(key)
+}
+
+func (g *dependencyGraph) direct(key string) []string {
+	g.Lock()
+	defer g.Unlock()
+	keys := make([]string, 0, len(g.dependents[key]))
+	for dependent := range g.dependents[key] {
+		keys = append(keys, dependent)
+	}
+	return keys
+}
+
+// invalidate removes every item depending on the given keys, following the dependents of each removed item.
+// Keys are visited at most once, so dependency cycles terminate.
+func (g *dependencyGraph) invalidate(keys []string) {
+	visited := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		visited[key] = struct{}{}
+	}
+
+	for len(keys) > 0 {
+		parent := keys[0]
+		keys = keys[1:]
+		for _, key := range g.direct(parent) {
+			if _, ok := visited[key]; ok {
+				continue
+			}
+			visited[key] = struct{}{}
+
+			shard := g.lock(key)
+			// The edge may be stale if the dependent was replaced
+			if itm, ok := shard.items[key]; ok && itm.dependsOnKey(parent) {
+				shard.remove(key, RemovedDependency)
+			}
+			keys = append(keys, shard.pending...)
+			shard.pending = nil
+			shard.Unlock()
+		}
+	}
+}
+
+// Records the item dependencies
+func (ms *CacheMapShared) link(key string, itm *Item) {
+	if ms.deps == nil {
+		return
+	}
+	ms.deps.Lock()
+	for _, parent := range itm.dependsOn {
+		keys, ok := ms.deps.dependents[parent]
+		if !ok {
+			keys = make(map[string]struct{})
+			ms.deps.dependents[parent] = keys
+		}
+		keys[key] = struct{}{}
+	}
+	ms.deps.Unlock()
+}
+
+// Removes the item dependencies, and queues the key for its dependents to be invalidated
+func (ms *CacheMapShared) unlink(key string, itm *Item) {
+	if ms.deps == nil {
+		return
+	}
+	ms.deps.Lock()
+	for _, parent := range itm.dependsOn {
+		if keys, ok := ms.deps.dependents[parent]; ok {
+			delete(keys, key)
+			if len(keys) == 0 {
+				delete(ms.deps.dependents, parent)
+			}
+		}
+	}
+	ms.deps.Unlock()
+	ms.changed(key)
+}
+
+// Queues the key so its dependents are invalidated once the shard is unlocked
+func (ms *CacheMapShared) changed(key string) {
+	if ms.deps == nil {
+		return
+	}
+	ms.deps.Lock()
+	_, hasDependents := ms.deps.dependents[key]
+	ms.deps.Unlock()
+
+	if hasDependents {
+		ms.pending = append(ms.pending, key)
+	}
+}
+
+// unlock releases the write lock, then invalidates the dependents of any items removed or replaced while it was held
+func (ms *CacheMapShared) unlock() {
+	pending := ms.pending
+	ms.pending = nil
+	ms.Unlock()
+	if len(pending) > 0 {
+		ms.deps.invalidate(pending)
+	}
+}
+
+func (i *Item) dependsOnKey(key string) bool {
+	for _, parent := range i.dependsOn {
+		if parent == key {
+			return true
+		}
+	}
+	return false
+}
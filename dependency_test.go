@@ -0,0 +1,56 @@
+package ttlmap_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/packaged/ttlmap"
+)
+
+func TestDependencyInvalidation(t *testing.T) {
+	cache := ttlmap.New(ttlmap.WithCleanupDuration(5 * time.Millisecond))
+	defer cache.Close()
+
+	removed := map[string]int{}
+	track := func(key string) ttlmap.ItemOption {
+		return ttlmap.WithOnDelete(func(*ttlmap.Item) { removed[key]++ })
+	}
+
+	cache.Set("model:1", 1, nil)
+	cache.Set("model:2", 2, nil)
+	cache.SetWithOptions("page", "html", ttlmap.WithDependencies("model:1", "model:2"), track("page"))
+	cache.SetWithOptions("fragment", "part", ttlmap.WithDependencies("page"), track("fragment"))
+
+	if deps := cache.Dependents("model:1"); len(deps) != 1 || deps[0] != "page" {
+		t.Fatalf("unexpected dependents %v", deps)
+	}
+
+	// Replacing a parent cascades through the chain
+	cache.Set("model:2", 22, nil)
+	if cache.Has("page") || cache.Has("fragment") {
+		t.Fatalf("expected dependents to be invalidated when a parent is replaced")
+	}
+	if removed["page"] != 1 || removed["fragment"] != 1 {
+		t.Fatalf("expected removal callbacks to fire, %v", removed)
+	}
+	if len(cache.Dependents("model:1")) != 0 {
+		t.Fatalf("expected removed dependents to be unlinked")
+	}
+
+	// Expiring a parent cascades
+	ttl := 10 * time.Millisecond
+	cache.Set("model:3", 3, &ttl)
+	cache.SetWithOptions("page3", "html", ttlmap.WithDependencies("model:3"))
+	time.Sleep(30 * time.Millisecond)
+	if cache.Has("page3") {
+		t.Fatalf("expected dependent to be invalidated when the parent expires")
+	}
+
+	// Cycles terminate
+	cache.SetWithOptions("a", 1, ttlmap.WithDependencies("b"))
+	cache.SetWithOptions("b", 2, ttlmap.WithDependencies("a"))
+	cache.Remove("a")
+	if cache.Has("a") || cache.Has("b") {
+		t.Fatalf("expected cyclic dependents to be removed")
+	}
+}
@@ -0,0 +1,303 @@
+package ttlmap
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// ErrNotSerializable is returned when a value can not be written to a DiskStore
+var ErrNotSerializable = errors.New("ttlmap: value is not []byte serializable")
+
+// diskRecordHeader is the size of the key and value length prefix on each record
+const diskRecordHeader = 8
+
+// DiskStore is a Store which keeps values in a local append-only data file, with an in-memory index of
+// offsets and expiries. Values must be a []byte, string or encoding.BinaryMarshaler and are read back as []byte.
+// When used as the L2 of a Tiered cache, promoted values are converted back to the type they were stored as.
+type DiskStore struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	size    int64 // end of the data file
+	dead    int64 // bytes held by removed or replaced records
+	index   map[string]*diskEntry
+	options cacheOptions
+
+	shutdown chan struct{}
+	ticker   *time.Ticker
+}
+
+type diskEntry struct {
+	offset   int64 // start of the value within the data file
+	length   int
+	ttl      time.Duration
+	expires  time.Time
+	deadline time.Time
+	onDelete func(*Item)
+}
+
+// OpenDiskStore creates a disk store using the data file at path, any existing content is truncated.
+// The default TTL, max lifetime and cleanup duration options are honoured the same as with New.
+func OpenDiskStore(path string, opts ...CacheOption) (*DiskStore, error) {
+	ds := &DiskStore{path: path, options: defaultCacheOptions(), index: make(map[string]*diskEntry)}
+	for _, opt := range opts {
+		opt(&ds.options)
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, err
+	}
+	ds.file = file
+
+	ds.shutdown = make(chan struct{})
+	ds.ticker = time.NewTicker(ds.options.cleanupDuration)
+	go func() {
+		for {
+			select {
+			case <-ds.shutdown:
+				return
+			case <-ds.ticker.C:
+				ds.Cleanup()
+			}
+		}
+	}()
+	return ds, nil
+}
+
+// Close stops the cleanup goroutine and closes the data file
+func (ds *DiskStore) Close() error {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	select {
+	case <-ds.shutdown:
+		// already closed
+		return nil
+	default:
+		close(ds.shutdown)
+	}
+	ds.ticker.Stop()
+	return ds.file.Close()
+}
+
+// Set writes the value to the data file under the specified key
+func (ds *DiskStore) Set(key string, value interface{}, duration *time.Duration) error {
+	return ds.set(key, value, duration, nil)
+}
+
+// SetWithCleanup writes the value to the data file.
+// Values which can not be written are not stored, and cleanup is called straight away with the RemovedRejected reason.
+func (ds *DiskStore) SetWithCleanup(key string, value interface{}, duration *time.Duration, cleanup func(*Item)) {
+	if err := ds.set(key, value, duration, cleanup); err != nil && cleanup != nil {
+		if duration == nil {
+			duration = &ds.options.defaultCacheDuration
+		}
+		itm := newItem(value, *duration, time.Now().Add(ds.options.maxLifetime), nil)
+		itm.reason = RemovedRejected
+		cleanup(itm)
+	}
+}
+
+func (ds *DiskStore) set(key string, value interface{}, duration *time.Duration, cleanup func(*Item)) error {
+	data, err := diskEncode(value)
+	if err != nil {
+		return err
+	}
+	if duration == nil {
+		duration = &ds.options.defaultCacheDuration
+	}
+
+	record := make([]byte, diskRecordHeader+len(key)+len(data))
+	binary.LittleEndian.PutUint32(record[0:4], uint32(len(key)))
+	binary.LittleEndian.PutUint32(record[4:8], uint32(len(data)))
+	copy(record[diskRecordHeader:], key)
+	copy(record[diskRecordHeader+len(key):], data)
+
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	if _, err = ds.file.WriteAt(record, ds.size); err != nil {
+		return err
+	}
+
+	if old, ok := ds.index[key]; ok {
+		ds.dead += diskRecordHeader + int64(len(key)+old.length)
+	}
+	now := time.Now()
+	ds.index[key] = &diskEntry{
+		offset:   ds.size + diskRecordHeader + int64(len(key)),
+		length:   len(data),
+		ttl:      *duration,
+		expires:  now.Add(*duration),
+		deadline: now.Add(ds.options.maxLifetime),
+		onDelete: cleanup,
+	}
+	ds.size += int64(len(record))
+	return nil
+}
+
+// Get reads the value for the key from the data file, and increases its expiry time if found
+func (ds *DiskStore) Get(key string) (interface{}, bool) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	e, ok := ds.index[key]
+	if !ok || e.expired(time.Now()) {
+		return nil, false
+	}
+	data, err := ds.read(e)
+	if err != nil {
+		return nil, false
+	}
+	e.expires = time.Now().Add(e.ttl)
+	return data, true
+}
+
+// Has checks to see if an unexpired item exists
+func (ds *DiskStore) Has(key string) bool {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	e, ok := ds.index[key]
+	return ok && !e.expired(time.Now())
+}
+
+// Remove removes an element from the store
+func (ds *DiskStore) Remove(key string) {
+	ds.mu.Lock()
+	ds.remove(key, RemovedDeleted)
+	ds.mu.Unlock()
+}
+
+func (ds *DiskStore) remove(key string, reason RemovalReason) {
+	e, ok := ds.index[key]
+	if !ok {
+		return
+	}
+	if e.onDelete != nil {
+		data, _ := ds.read(e)
+		itm := newItem(data, e.ttl, e.deadline, nil)
+		itm.expires = &e.expires
+		itm.reason = reason
+		e.onDelete(itm)
+	}
+	ds.dead += diskRecordHeader + int64(len(key)+e.length)
+	delete(ds.index, key)
+}
+
+// Len returns the number of entries in the index, including expired entries not yet cleaned up
+func (ds *DiskStore) Len() int {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	return len(ds.index)
+}
+
+// Cleanup removes any expired items, and compacts the data file once more than half of it is dead space
+func (ds *DiskStore) Cleanup() {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	now := time.Now()
+	for key, e := range ds.index {
+		if e.expired(now) {
+			ds.remove(key, RemovedExpired)
+		}
+	}
+	if ds.dead > 0 && ds.dead*2 >= ds.size {
+		_ = ds.compact()
+	}
+}
+
+// Compact rewrites the data file with only the live records
+func (ds *DiskStore) Compact() error {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	return ds.compact()
+}
+
+func (ds *DiskStore) compact() error {
+	tmpPath := ds.path + ".compact"
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	offsets := make(map[string]int64, len(ds.index))
+	var size int64
+	for key, e := range ds.index {
+		recordSize := diskRecordHeader + int64(len(key)+e.length)
+		record := make([]byte, recordSize)
+		if _, err = ds.file.ReadAt(record, e.offset-diskRecordHeader-int64(len(key))); err == nil {
+			_, err = tmp.WriteAt(record, size)
+		}
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmpPath)
+			return err
+		}
+		offsets[key] = size + diskRecordHeader + int64(len(key))
+		size += recordSize
+	}
+
+	if err = os.Rename(tmpPath, ds.path); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	ds.file.Close()
+	ds.file = tmp
+	for key, offset := range offsets {
+		ds.index[key].offset = offset
+	}
+	ds.size = size
+	ds.dead = 0
+	return nil
+}
+
+func (ds *DiskStore) read(e *diskEntry) ([]byte, error) {
+	data := make([]byte, e.length)
+	if _, err := ds.file.ReadAt(data, e.offset); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (e *diskEntry) expired(now time.Time) bool {
+	return e.expires.Before(now) || e.deadline.Before(now)
+}
+
+func diskEncode(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	case encoding.BinaryMarshaler:
+		return v.MarshalBinary()
+	}
+	return nil, ErrNotSerializable
+}
+
+// diskDecode converts data read from a DiskStore back to the type of the value originally written.
+// Returns the data unchanged if it can not be converted.
+func diskDecode(data []byte, typ reflect.Type) interface{} {
+	unmarshaler := reflect.TypeOf((*encoding.BinaryUnmarshaler)(nil)).Elem()
+	switch {
+	case typ == nil || typ == reflect.TypeOf(data):
+	case reflect.PtrTo(typ).Implements(unmarshaler):
+		v := reflect.New(typ)
+		if v.Interface().(encoding.BinaryUnmarshaler).UnmarshalBinary(data) == nil {
+			return v.Elem().Interface()
+		}
+	case typ.Kind() == reflect.Pointer && typ.Implements(unmarshaler):
+		v := reflect.New(typ.Elem())
+		if v.Interface().(encoding.BinaryUnmarshaler).UnmarshalBinary(data) == nil {
+			return v.Interface()
+		}
+	case typ.Kind() == reflect.String:
+		return reflect.ValueOf(string(data)).Convert(typ).Interface()
+	}
+	return data
+}
@@ -0,0 +1,126 @@
+package ttlmap_test
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/packaged/ttlmap"
+)
+
+func TestDiskStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.dat")
+	store, err := ttlmap.OpenDiskStore(path, ttlmap.WithDefaultTTL(50*time.Millisecond), ttlmap.WithCleanupDuration(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer store.Close()
+
+	if err = store.Set("bytes", []byte("one"), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	long := time.Second
+	if err = store.Set("string", "two", &long); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = store.Set("int", 3, nil); err != ttlmap.ErrNotSerializable {
+		t.Fatalf("expected ErrNotSerializable, got %v", err)
+	}
+
+	v, ok := store.Get("string")
+	if !ok || string(v.([]byte)) != "two" {
+		t.Fatalf("expected store to return `two`")
+	}
+
+	// Overwrite to create dead space, then let the short lived item expire
+	cleaned := 0
+	store.SetWithCleanup("bytes", []byte("uno"), nil, func(item *ttlmap.Item) {
+		if string(item.GetValue().([]byte)) == "uno" {
+			cleaned++
+		}
+	})
+	time.Sleep(100 * time.Millisecond)
+
+	if store.Has("bytes") {
+		t.Fatalf("expected item to expire")
+	}
+	if cleaned != 1 {
+		t.Fatalf("expected cleanup to be called on expiry")
+	}
+	if store.Len() != 1 {
+		t.Fatalf("expected a single live entry, got %d", store.Len())
+	}
+
+	// Compaction should have dropped the dead records
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Size() != int64(8+len("string")+len("two")) {
+		t.Fatalf("expected data file to be compacted, size %d", info.Size())
+	}
+	v, ok = store.Get("string")
+	if !ok || string(v.([]byte)) != "two" {
+		t.Fatalf("expected store to return `two` after compaction")
+	}
+}
+
+func TestDiskStoreAsTier(t *testing.T) {
+	l1 := ttlmap.New(ttlmap.WithDefaultTTL(50*time.Millisecond), ttlmap.WithCleanupDuration(5*time.Millisecond))
+	defer l1.Close()
+	l2, err := ttlmap.OpenDiskStore(filepath.Join(t.TempDir(), "tier.dat"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer l2.Close()
+
+	cache := ttlmap.NewTiered(l1, l2)
+	when := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	cache.Set("k", []byte("v"), nil)
+	cache.Set("s", "text", nil)
+	cache.Set("t", when, nil)
+	time.Sleep(75 * time.Millisecond)
+
+	v, ok := cache.Get("k")
+	if !ok || string(v.([]byte)) != "v" {
+		t.Fatalf("expected value to be read back from disk")
+	}
+	// Values keep the type they were stored as after a round trip through the disk
+	if v, ok := cache.Get("s"); !ok || v != "text" {
+		t.Fatalf("expected a string to be promoted as a string, got %#v", v)
+	}
+	if v, ok := cache.Get("t"); !ok || !v.(time.Time).Equal(when) {
+		t.Fatalf("expected a BinaryMarshaler to be promoted as its own type, got %#v", v)
+	}
+	if v, ok := l1.Get("s"); !ok || v != "text" {
+		t.Fatalf("expected the promoted string in L1, got %#v", v)
+	}
+}
+
+func TestDiskStoreRejectsUnserializableTier(t *testing.T) {
+	l1 := ttlmap.New(ttlmap.WithDefaultTTL(10*time.Millisecond), ttlmap.WithCleanupDuration(5*time.Millisecond))
+	defer l1.Close()
+	l2, err := ttlmap.OpenDiskStore(filepath.Join(t.TempDir(), "tier.dat"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer l2.Close()
+
+	var reason atomic.Value
+	cache := ttlmap.NewTiered(l1, l2)
+	cache.SetWithCleanup("k", 42, nil, func(item *ttlmap.Item) {
+		if item.GetValue().(int) == 42 {
+			reason.Store(item.RemovalReason())
+		}
+	})
+	time.Sleep(40 * time.Millisecond)
+
+	if reason.Load() != ttlmap.RemovedRejected {
+		t.Fatalf("expected cleanup to fire when the value can not be demoted, got %v", reason.Load())
+	}
+	if _, ok := cache.Get("k"); ok {
+		t.Fatalf("expected rejected value to be missing")
+	}
+}
@@ -0,0 +1,43 @@
+package ttlmap_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/packaged/ttlmap"
+)
+
+func TestExpirationPolicies(t *testing.T) {
+	cache := ttlmap.New(ttlmap.WithCleanupDuration(5 * time.Millisecond))
+	defer cache.Close()
+
+	ttl := 40 * time.Millisecond
+	cache.SetWithOptions("fixed", 1, ttlmap.WithTTL(ttl), ttlmap.WithFixedExpiry())
+	cache.SetWithOptions("sliding", 2, ttlmap.WithTTL(ttl))
+	cache.SetWithOptions("capped", 3, ttlmap.WithTTL(ttl), ttlmap.WithItemMaxLifetime(70*time.Millisecond))
+	cache.SetUntil("until", 4, time.Now().Add(ttl))
+
+	itm, _ := cache.GetItem("until")
+	if itm.IsSliding() || !itm.GetDeadline().Equal(itm.GetExpiry()) {
+		t.Fatalf("expected absolute item to have a fixed expiry at its deadline")
+	}
+
+	// Keep reading every item, only the sliding items are extended
+	for i := 0; i < 5; i++ {
+		time.Sleep(20 * time.Millisecond)
+		cache.Get("fixed")
+		cache.Get("sliding")
+		cache.Get("capped")
+		cache.Get("until")
+	}
+
+	if cache.Has("fixed") || cache.Has("until") {
+		t.Fatalf("expected reads not to extend fixed and absolute items")
+	}
+	if !cache.Has("sliding") {
+		t.Fatalf("expected reads to extend sliding items")
+	}
+	if cache.Has("capped") {
+		t.Fatalf("expected per item max lifetime to expire the sliding item")
+	}
+}
@@ -2,7 +2,6 @@ package ttlmap
 
 import (
 	"errors"
-	"time"
 )
 
 // ErrTypeMismatch is returned when the cached value cannot be cast to the requested generic type.
@@ -14,10 +13,10 @@ func Fetch[T any](m CacheMap, key string, source func(string) (T, error)) (T, er
 	var returnValue T
 	var okCast bool
 
-	shard := m.GetShard(key)
-	shard.RLock()
+	shard := m.rlockShard(key)
 	itm, ok := shard.items[key]
-	if ok {
+	if ok && itm.access(false) {
+		m.stats.lookup(true)
 		returnValue, okCast = itm.GetValue().(T)
 		shard.RUnlock()
 		if !okCast {
@@ -45,12 +44,18 @@ func Fetch[T any](m CacheMap, key string, source func(string) (T, error)) (T, er
 		return returnValue, nil
 	}
 	shard.RUnlock()
-	shard.Lock()
-	defer shard.Unlock()
+	shard = m.lockShard(key)
+	defer shard.unlock()
 
 	itm, ok = shard.items[key]
+	if ok && !itm.access(false) {
+		// Items with no reads remaining are not served stale
+		shard.remove(key, itm.expiryReason())
+		ok = false
+	}
 	if ok {
 		// check the value was not already processed when waiting for the lock
+		m.stats.lookup(true)
 		returnValue, okCast = itm.GetValue().(T)
 		if !okCast {
 			return zero, ErrTypeMismatch
@@ -58,10 +63,11 @@ func Fetch[T any](m CacheMap, key string, source func(string) (T, error)) (T, er
 		return returnValue, nil
 	}
 
+	m.stats.lookup(false)
 	value, err := source(key)
 	if err == nil {
-		itm = newItem(value, m.options.defaultCacheDuration, time.Now().Add(m.options.maxLifetime), nil)
-		shard.items[key] = itm
+		shard.set(key, m.newItem(value, itemOptions{}))
+		m.grow(shard)
 	}
 	return value, err
 }
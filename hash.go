@@ -0,0 +1,15 @@
+package ttlmap
+
+import "hash/maphash"
+
+// Hasher maps a key to the hash used to pick its shard
+type Hasher func(key string) uint64
+
+// newSeededHasher returns a maphash based hasher with a random seed, so shard placement differs between caches
+// and processes and can not be targeted by choosing keys
+func newSeededHasher() Hasher {
+	seed := maphash.MakeSeed()
+	return func(key string) uint64 {
+		return maphash.String(seed, key)
+	}
+}
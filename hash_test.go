@@ -0,0 +1,58 @@
+package ttlmap_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/packaged/ttlmap"
+)
+
+func TestShardCountPowerOfTwo(t *testing.T) {
+	cache := ttlmap.New(ttlmap.WithShardSize(10))
+	defer cache.Close()
+	if n := cache.ShardCount(); n != 16 {
+		t.Fatalf("expected shard count to round up to 16, got %d", n)
+	}
+}
+
+func TestWithHasher(t *testing.T) {
+	cache := ttlmap.New(ttlmap.WithShardSize(8), ttlmap.WithHasher(func(key string) uint64 { return 3 }))
+	defer cache.Close()
+
+	for i := 0; i < 100; i++ {
+		cache.Set("k"+strconv.Itoa(i), i, nil)
+	}
+	b := cache.ShardBalance()
+	if len(b.Entries) != 8 || b.Entries[3] != 100 || b.Min != 0 || b.Max != 100 || b.Skew != 8 {
+		t.Fatalf("expected every key in shard 3, got %+v", b)
+	}
+	if v, ok := cache.Get("k42"); !ok || v.(int) != 42 {
+		t.Fatalf("expected item to be found with the custom hasher")
+	}
+}
+
+func TestDefaultHasherIsSeeded(t *testing.T) {
+	a := ttlmap.New()
+	b := ttlmap.New()
+	defer a.Close()
+	defer b.Close()
+
+	for i := 0; i < 1000; i++ {
+		key := "/path/" + strconv.Itoa(i)
+		a.Set(key, i, nil)
+		b.Set(key, i, nil)
+	}
+	ba, bb := a.ShardBalance(), b.ShardBalance()
+	if ba.Skew > 2 || bb.Skew > 2 {
+		t.Fatalf("expected keys to be spread evenly, skew %v and %v", ba.Skew, bb.Skew)
+	}
+	same := true
+	for i := range ba.Entries {
+		if ba.Entries[i] != bb.Entries[i] {
+			same = false
+		}
+	}
+	if same {
+		t.Fatalf("expected caches to place keys differently")
+	}
+}
@@ -2,19 +2,36 @@ package ttlmap
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // Item represents a record in the map
 type Item struct {
 	sync.RWMutex
-	updateMutex sync.RWMutex
-	isUpdating  bool
-	data        interface{}
-	deadline    time.Time
-	ttl         time.Duration
-	expires     *time.Time
-	onDelete    func(*Item)
+	updateMutex     sync.RWMutex
+	isUpdating      bool
+	data            interface{}
+	deadline        time.Time
+	ttl             time.Duration
+	expires         *time.Time
+	onDelete        func(*Item)
+	tags            []string
+	dependsOn       []string
+	cost            int64
+	ns              *namespaceState
+	version         uint64
+	fixed           bool // reads do not extend the expiry
+	defaultLifetime bool // deadline follows the cache max lifetime
+	pinned          bool // never expires
+	maxReads        int64
+	maxIdle         time.Duration
+	created         time.Time
+	updated         time.Time
+	reads           atomic.Int64
+	lastAccess      atomic.Int64 // unix nanoseconds
+	reason          RemovalReason
+	slot            int // index of the key within the shard keys
 }
 
 func newItem(value interface{}, duration time.Duration, deadline time.Time, onDelete func(*Item)) *Item {
@@ -24,14 +41,59 @@ func newItem(value interface{}, duration time.Duration, deadline time.Time, onDe
 		deadline: deadline,
 		onDelete: onDelete,
 	}
-	expiry := time.Now().Add(duration)
+	now := time.Now()
+	expiry := now.Add(duration)
 	i.expires = &expiry
+	i.created = now
+	i.updated = now
+	i.lastAccess.Store(now.UnixNano())
 	return i
 }
 
+// snapshot returns a copy of the item, detached from the cache
+func (i *Item) snapshot() *Item {
+	i.RLock()
+	defer i.RUnlock()
+	s := &Item{
+		data:      i.data,
+		deadline:  i.deadline,
+		ttl:       i.ttl,
+		expires:   i.expires,
+		tags:      i.tags,
+		dependsOn: i.dependsOn,
+		cost:      i.cost,
+		version:   i.version,
+		fixed:     i.fixed,
+		pinned:    i.pinned,
+		maxReads:  i.maxReads,
+		maxIdle:   i.maxIdle,
+		reason:    i.reason,
+		created:   i.created,
+		updated:   i.updated,
+	}
+	s.reads.Store(i.reads.Load())
+	s.lastAccess.Store(i.lastAccess.Load())
+	return s
+}
+
 // Touch increases the expiry time on the item by the TTL
 func (i *Item) Touch() {
+	i.touch(0)
+}
+
+// readTouch increases the expiry time for a read, unless the item has a fixed expiry
+func (i *Item) readTouch() {
+	if !i.fixed {
+		i.touch(0)
+	}
+}
+
+// touch increases the expiry time by the given TTL, replacing the item TTL when greater than zero
+func (i *Item) touch(ttl time.Duration) {
 	i.Lock()
+	if ttl > 0 {
+		i.ttl = ttl
+	}
 	expiration := time.Now().Add(i.ttl)
 	i.expires = &expiration
 	i.Unlock()
@@ -39,9 +101,14 @@ func (i *Item) Touch() {
 
 // Expired returns if the item has passed its expiry time
 func (i *Item) Expired() bool {
+	if i.exhausted() {
+		return true
+	}
 	var value bool
 	i.RLock()
-	if i.expires == nil || i.deadline.Before(time.Now()) {
+	if i.pinned {
+		value = false
+	} else if i.expires == nil || i.deadline.Before(time.Now()) {
 		value = true
 	} else {
 		value = i.expires.Before(time.Now())
@@ -52,6 +119,9 @@ func (i *Item) Expired() bool {
 
 // GetValue represents the value of the item in the map
 func (i *Item) GetValue() interface{} {
+	if cv, ok := i.data.(*compressedValue); ok {
+		return cv.value()
+	}
 	return i.data
 }
 
@@ -65,3 +135,96 @@ func (i *Item) GetExpiry() time.Time {
 func (i *Item) GetDeadline() time.Time {
 	return i.deadline
 }
+
+// GetTags returns the tags attached to the item
+func (i *Item) GetTags() []string {
+	return i.tags
+}
+
+// GetDependencies returns the keys the item depends on
+func (i *Item) GetDependencies() []string {
+	return i.dependsOn
+}
+
+// GetCost returns the cost assigned to the item
+func (i *Item) GetCost() int64 {
+	return i.cost
+}
+
+// GetVersion returns the version of the item, which changes each time the item is written
+func (i *Item) GetVersion() uint64 {
+	return i.version
+}
+
+// IsSliding returns true when reads increase the expiry time of the item
+func (i *Item) IsSliding() bool {
+	return !i.fixed
+}
+
+// IsPinned returns true when the item is pinned, and does not expire
+func (i *Item) IsPinned() bool {
+	i.RLock()
+	defer i.RUnlock()
+	return i.pinned
+}
+
+// GetCreated returns when the item was stored
+func (i *Item) GetCreated() time.Time {
+	return i.created
+}
+
+// GetLastUpdate returns when the item value was last written
+func (i *Item) GetLastUpdate() time.Time {
+	return i.updated
+}
+
+// GetLastAccess returns when the item was last read, or when it was stored if it has not been read
+func (i *Item) GetLastAccess() time.Time {
+	return time.Unix(0, i.lastAccess.Load())
+}
+
+// GetAccessCount returns the number of times the item has been read
+func (i *Item) GetAccessCount() int64 {
+	return i.reads.Load()
+}
+
+// RemovalReason returns why the item was removed, for use within removal callbacks
+func (i *Item) RemovalReason() RemovalReason {
+	return i.reason
+}
+
+// access records a read of the item, returning false if it is idle or has no reads remaining
+func (i *Item) access(touch bool) bool {
+	now := time.Now()
+	if i.idle(now) {
+		return false
+	}
+	if reads := i.reads.Add(1); i.maxReads > 0 && reads > i.maxReads {
+		return false
+	}
+	i.lastAccess.Store(now.UnixNano())
+	if touch {
+		i.readTouch()
+	}
+	return true
+}
+
+// exhausted returns true once the item has been read the max number of times, or has been idle too long
+func (i *Item) exhausted() bool {
+	return (i.maxReads > 0 && i.reads.Load() >= i.maxReads) || i.idle(time.Now())
+}
+
+func (i *Item) idle(now time.Time) bool {
+	return i.maxIdle > 0 && now.Sub(time.Unix(0, i.lastAccess.Load())) > i.maxIdle
+}
+
+// expiryReason returns the reason an expired item is removed
+func (i *Item) expiryReason() RemovalReason {
+	if i.maxReads > 0 && i.reads.Load() >= i.maxReads {
+		return RemovedMaxReads
+	}
+	if i.idle(time.Now()) {
+		return RemovedIdle
+	}
+	return RemovedExpired
+}
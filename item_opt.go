@@ -0,0 +1,98 @@
+package ttlmap
+
+import "time"
+
+type itemOptions struct {
+	duration    *time.Duration
+	maxLifetime *time.Duration
+	until       *time.Time
+	deadline    *time.Time // an existing deadline, kept when an item moves between tiers
+	fixed       bool
+	pinned      bool
+	maxReads    int64
+	maxIdle     time.Duration
+	onDelete    func(*Item)
+	tags        []string
+	dependsOn   []string
+	cost        int64
+}
+
+// ItemOption configures how an item is stored
+type ItemOption func(options *itemOptions)
+
+// WithTTL Sets the duration for the item, in place of the cache default
+func WithTTL(ttl time.Duration) ItemOption {
+	return func(o *itemOptions) {
+		o.duration = &ttl
+	}
+}
+
+// WithOnDelete Sets a callback for when the item is removed from the cache
+func WithOnDelete(cleanup func(*Item)) ItemOption {
+	return func(o *itemOptions) {
+		o.onDelete = cleanup
+	}
+}
+
+// WithTags Attaches tags to the item, allowing it to be removed with InvalidateTag
+func WithTags(tags ...string) ItemOption {
+	return func(o *itemOptions) {
+		o.tags = append(o.tags, tags...)
+	}
+}
+
+// WithDependencies Removes the item whenever any of the given keys are removed, replaced or expire
+func WithDependencies(keys ...string) ItemOption {
+	return func(o *itemOptions) {
+		o.dependsOn = append(o.dependsOn, keys...)
+	}
+}
+
+// WithCost Sets the cost of the item, counted against namespace cost quotas
+func WithCost(cost int64) ItemOption {
+	return func(o *itemOptions) {
+		o.cost = cost
+	}
+}
+
+// WithFixedExpiry Stops reads from increasing the expiry time of the item
+func WithFixedExpiry() ItemOption {
+	return func(o *itemOptions) {
+		o.fixed = true
+	}
+}
+
+// WithExpiresAt Expires the item at the given time, reads do not increase the expiry time
+func WithExpiresAt(until time.Time) ItemOption {
+	return func(o *itemOptions) {
+		o.until = &until
+	}
+}
+
+// WithItemMaxLifetime Sets the maximum amount of time the item can exist, in place of the cache max lifetime
+func WithItemMaxLifetime(ttl time.Duration) ItemOption {
+	return func(o *itemOptions) {
+		o.maxLifetime = &ttl
+	}
+}
+
+// WithPin Pins the item so it ignores its TTL and max lifetime, it is only removed explicitly
+func WithPin() ItemOption {
+	return func(o *itemOptions) {
+		o.pinned = true
+	}
+}
+
+// WithMaxReads Removes the item once it has been read the given number of times
+func WithMaxReads(reads int64) ItemOption {
+	return func(o *itemOptions) {
+		o.maxReads = reads
+	}
+}
+
+// WithMaxIdle Removes the item when it has not been read within the given duration, regardless of its TTL
+func WithMaxIdle(idle time.Duration) ItemOption {
+	return func(o *itemOptions) {
+		o.maxIdle = idle
+	}
+}
@@ -0,0 +1,48 @@
+package ttlmap_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/packaged/ttlmap"
+)
+
+func TestItemAccessMetadata(t *testing.T) {
+	cache := ttlmap.New()
+	defer cache.Close()
+
+	before := time.Now()
+	cache.Set("k", 1, nil)
+	itm, _ := cache.GetItem("k")
+	if itm.GetCreated().Before(before) || itm.GetAccessCount() != 0 || !itm.GetLastUpdate().Equal(itm.GetCreated()) {
+		t.Fatalf("unexpected metadata for a new item")
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	cache.Get("k")
+	cache.TouchGet("k", false)
+	ttlmap.Fetch[int](cache, "k", func(string) (int, error) { return 0, nil })
+	cache.MGet([]string{"k"})
+
+	itm, _ = cache.GetItem("k")
+	if itm.GetAccessCount() != 4 {
+		t.Fatalf("expected 4 accesses, got %d", itm.GetAccessCount())
+	}
+	if !itm.GetLastAccess().After(itm.GetCreated()) {
+		t.Fatalf("expected last access to move on read")
+	}
+
+	cache.Increment("counter", nil)
+	time.Sleep(5 * time.Millisecond)
+	cache.Increment("counter", nil)
+	var counter *ttlmap.Item
+	cache.Range(func(key string, item *ttlmap.Item) bool {
+		if key == "counter" {
+			counter = item
+		}
+		return true
+	})
+	if counter == nil || !counter.GetLastUpdate().After(counter.GetCreated()) {
+		t.Fatalf("expected in place updates to move the last update time")
+	}
+}
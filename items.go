@@ -4,8 +4,9 @@ package ttlmap
 func (m CacheMap) Items() map[string]interface{} {
 	tmp := make(map[string]interface{})
 
-	for i := 0; i < m.options.shardCount; i++ {
-		shard := m.items[i]
+	t := m.shards.pin()
+	defer m.shards.unpin()
+	for _, shard := range t.all {
 		shard.RLock()
 		for key, itm := range shard.items {
 			if !itm.Expired() {
@@ -16,3 +17,77 @@ func (m CacheMap) Items() map[string]interface{} {
 	}
 	return tmp
 }
+
+type rangeEntry struct {
+	key  string
+	item *Item
+}
+
+// Range calls fn with a copy of each unexpired item until fn returns false.
+// Each shard is copied under its read lock before fn is called, so fn sees a consistent view of each shard
+// and may modify the cache, but items changed in shards not yet visited are seen in their new state.
+func (m CacheMap) Range(fn func(key string, item *Item) bool) {
+	var entries []rangeEntry
+	t := m.shards.pin()
+	defer m.shards.unpin()
+	for _, shard := range t.all {
+		entries = entries[:0]
+		shard.RLock()
+		for key, itm := range shard.items {
+			if !itm.Expired() {
+				entries = append(entries, rangeEntry{key: key, item: itm.snapshot()})
+			}
+		}
+		shard.RUnlock()
+
+		for _, e := range entries {
+			if !fn(e.key, e.item) {
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over the keys and values of unexpired items, with the same guarantees as Range
+func (m CacheMap) All() func(yield func(string, interface{}) bool) {
+	return func(yield func(string, interface{}) bool) {
+		m.Range(func(key string, item *Item) bool {
+			return yield(key, item.GetValue())
+		})
+	}
+}
+
+// Keys returns an iterator over the keys of unexpired items, with the same guarantees as Range
+func (m CacheMap) Keys() func(yield func(string) bool) {
+	return func(yield func(string) bool) {
+		m.Range(func(key string, item *Item) bool {
+			return yield(key)
+		})
+	}
+}
+
+// Values returns an iterator over the values of unexpired items, with the same guarantees as Range
+func (m CacheMap) Values() func(yield func(interface{}) bool) {
+	return func(yield func(interface{}) bool) {
+		m.Range(func(key string, item *Item) bool {
+			return yield(item.GetValue())
+		})
+	}
+}
+
+// Len returns the number of unexpired items
+func (m CacheMap) Len() int {
+	count := 0
+	t := m.shards.pin()
+	defer m.shards.unpin()
+	for _, shard := range t.all {
+		shard.RLock()
+		for _, itm := range shard.items {
+			if !itm.Expired() {
+				count++
+			}
+		}
+		shard.RUnlock()
+	}
+	return count
+}
@@ -0,0 +1,61 @@
+package ttlmap_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/packaged/ttlmap"
+)
+
+func TestRange(t *testing.T) {
+	cache := ttlmap.New()
+	defer cache.Close()
+
+	expired := time.Millisecond
+	for i := 0; i < 10; i++ {
+		cache.Set("k"+strconv.Itoa(i), i, nil)
+	}
+	cache.Set("expired", -1, &expired)
+	time.Sleep(5 * time.Millisecond)
+
+	if cache.Len() != 10 {
+		t.Fatalf("expected 10 live items, got %d", cache.Len())
+	}
+
+	sum := 0
+	cache.Range(func(key string, item *ttlmap.Item) bool {
+		sum += item.GetValue().(int)
+		// Modifying the cache during iteration is allowed
+		cache.Remove(key)
+		return true
+	})
+	if sum != 45 || cache.Len() != 0 {
+		t.Fatalf("expected range to visit every live item, sum %d", sum)
+	}
+
+	for i := 0; i < 10; i++ {
+		cache.Set("k"+strconv.Itoa(i), i, nil)
+	}
+	visited := 0
+	cache.Range(func(key string, item *ttlmap.Item) bool {
+		visited++
+		return visited < 3
+	})
+	if visited != 3 {
+		t.Fatalf("expected range to stop early, visited %d", visited)
+	}
+
+	keys, values := 0, 0
+	cache.All()(func(key string, value interface{}) bool {
+		if cache.Items()[key] != value {
+			t.Fatalf("unexpected value for %s", key)
+		}
+		return true
+	})
+	cache.Keys()(func(string) bool { keys++; return true })
+	cache.Values()(func(interface{}) bool { values++; return values < 5 })
+	if keys != 10 || values != 5 {
+		t.Fatalf("unexpected iterator counts, keys %d values %d", keys, values)
+	}
+}
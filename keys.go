@@ -0,0 +1,71 @@
+package ttlmap
+
+import (
+	"path"
+	"strings"
+)
+
+// KeysWithPrefix returns the keys of all unexpired items starting with the prefix
+func (m CacheMap) KeysWithPrefix(prefix string) []string {
+	return m.matchingKeys(func(key string) bool { return strings.HasPrefix(key, prefix) })
+}
+
+// KeysMatching returns the keys of all unexpired items matching the pattern, using the path.Match syntax
+func (m CacheMap) KeysMatching(pattern string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	return m.matchingKeys(func(key string) bool {
+		ok, _ := path.Match(pattern, key)
+		return ok
+	}), nil
+}
+
+// RemovePrefix removes every item with a key starting with the prefix, returning the number of items removed
+func (m CacheMap) RemovePrefix(prefix string) int {
+	return m.removeMatching(func(key string) bool { return strings.HasPrefix(key, prefix) })
+}
+
+// RemoveMatching removes every item with a key matching the pattern, using the path.Match syntax
+func (m CacheMap) RemoveMatching(pattern string) (int, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return 0, err
+	}
+	return m.removeMatching(func(key string) bool {
+		ok, _ := path.Match(pattern, key)
+		return ok
+	}), nil
+}
+
+func (m CacheMap) matchingKeys(match func(string) bool) []string {
+	var keys []string
+	t := m.shards.pin()
+	defer m.shards.unpin()
+	for _, shard := range t.all {
+		shard.RLock()
+		for key, itm := range shard.items {
+			if match(key) && !itm.Expired() {
+				keys = append(keys, key)
+			}
+		}
+		shard.RUnlock()
+	}
+	return keys
+}
+
+func (m CacheMap) removeMatching(match func(string) bool) int {
+	removed := 0
+	t := m.shards.pin()
+	defer m.shards.unpin()
+	for _, shard := range t.all {
+		shard.Lock()
+		for key := range shard.items {
+			if match(key) {
+				shard.remove(key, RemovedDeleted)
+				removed++
+			}
+		}
+		shard.unlock()
+	}
+	return removed
+}
@@ -0,0 +1,42 @@
+package ttlmap_test
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/packaged/ttlmap"
+)
+
+func TestPrefixOperations(t *testing.T) {
+	cache := ttlmap.New()
+	defer cache.Close()
+
+	cache.Set("user:1:profile", 1, nil)
+	cache.Set("user:1:settings", 2, nil)
+	cache.Set("user:2:profile", 3, nil)
+	cache.Set("order:1", 4, nil)
+
+	keys := cache.KeysWithPrefix("user:1:")
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "user:1:profile" || keys[1] != "user:1:settings" {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+
+	keys, err := cache.KeysMatching("user:*:profile")
+	if err != nil || len(keys) != 2 {
+		t.Fatalf("unexpected keys %v (%v)", keys, err)
+	}
+	if _, err = cache.KeysMatching("user:["); err == nil {
+		t.Fatalf("expected bad pattern error")
+	}
+
+	if n := cache.RemovePrefix("user:1:"); n != 2 {
+		t.Fatalf("expected 2 items removed, got %d", n)
+	}
+	if n, err := cache.RemoveMatching("*:profile"); err != nil || n != 1 {
+		t.Fatalf("expected 1 item removed, got %d (%v)", n, err)
+	}
+	if items := cache.Items(); len(items) != 1 || items["order:1"] != 4 {
+		t.Fatalf("unexpected remaining items %v", items)
+	}
+}
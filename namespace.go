@@ -0,0 +1,231 @@
+package ttlmap
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrQuotaExceeded is returned when storing an item would exceed a namespace quota
+var ErrQuotaExceeded = errors.New("ttlmap: namespace quota exceeded")
+
+type namespaceOptions struct {
+	defaultCacheDuration *time.Duration
+	maxLifetime          *time.Duration
+	maxEntries           int64
+	maxCost              int64
+}
+
+// NamespaceOption configures a namespace view
+type NamespaceOption func(options *namespaceOptions)
+
+// WithNamespaceTTL Sets the default duration for items stored in the namespace
+func WithNamespaceTTL(ttl time.Duration) NamespaceOption {
+	return func(o *namespaceOptions) {
+		o.defaultCacheDuration = &ttl
+	}
+}
+
+// WithNamespaceMaxLifetime Sets the maximum amount of time an item can exist within the namespace
+func WithNamespaceMaxLifetime(ttl time.Duration) NamespaceOption {
+	return func(o *namespaceOptions) {
+		o.maxLifetime = &ttl
+	}
+}
+
+// WithNamespaceMaxEntries Limits the number of items the namespace can hold
+func WithNamespaceMaxEntries(entries int64) NamespaceOption {
+	return func(o *namespaceOptions) {
+		o.maxEntries = entries
+	}
+}
+
+// WithNamespaceMaxCost Limits the total cost of the items the namespace can hold
+func WithNamespaceMaxCost(cost int64) NamespaceOption {
+	return func(o *namespaceOptions) {
+		o.maxCost = cost
+	}
+}
+
+// NamespaceStats holds the counters for a namespace
+type NamespaceStats struct {
+	Hits    uint64
+	Misses  uint64
+	Entries int64 // items held, including expired items not yet cleaned up
+	Cost    int64
+}
+
+type namespaceRegistry struct {
+	sync.Mutex
+	states map[string]*namespaceState
+}
+
+// namespaceState is shared by every view of the same namespace
+type namespaceState struct {
+	sync.Mutex
+	prefix  string
+	options namespaceOptions
+	entries int64
+	cost    int64
+	hits    atomic.Uint64
+	misses  atomic.Uint64
+}
+
+// Namespace is a view of a CacheMap with its keys isolated under a prefix, and its own defaults, quotas and stats
+type Namespace struct {
+	cache CacheMap
+	state *namespaceState
+}
+
+// Namespace returns a view of the cache for the named namespace.
+// Views of the same name share their items, options, quotas and stats. Options passed when the namespace
+// already exists update it for every view, options not passed are left unchanged.
+func (m CacheMap) Namespace(name string, opts ...NamespaceOption) *Namespace {
+	m.namespaces.Lock()
+	state, ok := m.namespaces.states[name]
+	if !ok {
+		state = &namespaceState{prefix: namespacePrefix(name)}
+		m.namespaces.states[name] = state
+	}
+	m.namespaces.Unlock()
+
+	state.Lock()
+	for _, opt := range opts {
+		opt(&state.options)
+	}
+	state.Unlock()
+	return &Namespace{cache: m, state: state}
+}
+
+// FlushNamespace removes every item in the named namespace, returning the number of items removed
+func (m CacheMap) FlushNamespace(name string) int {
+	return m.InvalidateTag(namespacePrefix(name))
+}
+
+// namespacePrefix is also used as the namespace key within the shard tag index
+func namespacePrefix(name string) string {
+	return "\x00" + name + "\x00"
+}
+
+// Set sets the given value under the specified key within the namespace
+func (n *Namespace) Set(key string, value interface{}, duration *time.Duration) error {
+	return n.set(key, value, itemOptions{duration: duration})
+}
+
+// SetWithOptions sets the given value under the specified key within the namespace, configured with the item options
+func (n *Namespace) SetWithOptions(key string, value interface{}, opts ...ItemOption) error {
+	o := itemOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return n.set(key, value, o)
+}
+
+func (n *Namespace) set(key string, value interface{}, o itemOptions) error {
+	n.state.Lock()
+	options := n.state.options
+	n.state.Unlock()
+	if o.duration == nil {
+		o.duration = options.defaultCacheDuration
+	}
+	if o.maxLifetime == nil {
+		o.maxLifetime = options.maxLifetime
+	}
+	key = n.state.prefix + key
+	// Dependencies are keys within the namespace
+	dependsOn := make([]string, len(o.dependsOn))
+	for i, parent := range o.dependsOn {
+		dependsOn[i] = n.state.prefix + parent
+	}
+	o.dependsOn = dependsOn
+	itm := n.cache.newItem(value, o)
+	itm.ns = n.state
+
+	shard := n.cache.lockShard(key)
+	if err := n.state.reserve(itm, shard.items[key]); err != nil {
+		shard.Unlock()
+		return err
+	}
+	shard.set(key, itm)
+	n.cache.grow(shard)
+	shard.unlock()
+	return nil
+}
+
+// TouchGet retrieves an item from the namespace, and optionally increase its expiry time if found
+func (n *Namespace) TouchGet(key string, touch bool) (interface{}, bool) {
+	value, ok := n.cache.TouchGet(n.state.prefix+key, touch)
+	if ok {
+		n.state.hits.Add(1)
+	} else {
+		n.state.misses.Add(1)
+	}
+	return value, ok
+}
+
+// Get retrieves an item from the namespace, and increase its expiry time if found
+func (n *Namespace) Get(key string) (interface{}, bool) {
+	return n.TouchGet(key, true)
+}
+
+// Has checks to see if an item exists within the namespace
+func (n *Namespace) Has(key string) bool {
+	return n.cache.Has(n.state.prefix + key)
+}
+
+// Remove removes an element from the namespace
+func (n *Namespace) Remove(key string) {
+	n.cache.Remove(n.state.prefix + key)
+}
+
+// Len returns the number of items held, including expired items not yet cleaned up
+func (n *Namespace) Len() int {
+	n.state.Lock()
+	defer n.state.Unlock()
+	return int(n.state.entries)
+}
+
+// Flush removes every item in the namespace, returning the number of items removed
+func (n *Namespace) Flush() int {
+	return n.cache.InvalidateTag(n.state.prefix)
+}
+
+// Stats returns the counters for the namespace
+func (n *Namespace) Stats() NamespaceStats {
+	n.state.Lock()
+	defer n.state.Unlock()
+	return NamespaceStats{
+		Hits:    n.state.hits.Load(),
+		Misses:  n.state.misses.Load(),
+		Entries: n.state.entries,
+		Cost:    n.state.cost,
+	}
+}
+
+// reserve counts the item against the namespace, failing if it would exceed a quota.
+// old is the item being replaced, which is released by the shard once the new item is stored.
+func (s *namespaceState) reserve(itm, old *Item) error {
+	s.Lock()
+	defer s.Unlock()
+	o := s.options
+	entries, cost := s.entries+1, s.cost+itm.cost
+	if old != nil && old.ns == s {
+		entries--
+		cost -= old.cost
+	}
+	if (o.maxEntries > 0 && entries > o.maxEntries) || (o.maxCost > 0 && cost > o.maxCost) {
+		return ErrQuotaExceeded
+	}
+	s.entries++
+	s.cost += itm.cost
+	return nil
+}
+
+// release stops counting the item against the namespace
+func (s *namespaceState) release(itm *Item) {
+	s.Lock()
+	s.entries--
+	s.cost -= itm.cost
+	s.Unlock()
+}
@@ -0,0 +1,126 @@
+package ttlmap_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/packaged/ttlmap"
+)
+
+func TestNamespaceIsolation(t *testing.T) {
+	cache := ttlmap.New(ttlmap.WithCleanupDuration(5 * time.Millisecond))
+	defer cache.Close()
+
+	users := cache.Namespace("users", ttlmap.WithNamespaceTTL(20*time.Millisecond))
+	orders := cache.Namespace("orders")
+
+	if err := users.Set("1", "alice", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	orders.Set("1", "order", nil)
+	cache.Set("1", "global", nil)
+
+	if v, ok := users.Get("1"); !ok || v.(string) != "alice" {
+		t.Fatalf("expected users namespace to return `alice`")
+	}
+	if v, ok := orders.Get("1"); !ok || v.(string) != "order" {
+		t.Fatalf("expected orders namespace to return `order`")
+	}
+	if v, ok := cache.Get("1"); !ok || v.(string) != "global" {
+		t.Fatalf("expected parent cache to return `global`")
+	}
+	orders.Get("2")
+
+	// Namespace default TTL applies
+	time.Sleep(40 * time.Millisecond)
+	if users.Has("1") || users.Len() != 0 {
+		t.Fatalf("expected users item to expire with the namespace TTL")
+	}
+
+	if stats := orders.Stats(); stats.Hits != 1 || stats.Misses != 1 || stats.Entries != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	if n := cache.FlushNamespace("orders"); n != 1 {
+		t.Fatalf("expected 1 item flushed, got %d", n)
+	}
+	if orders.Has("1") || !cache.Has("1") {
+		t.Fatalf("expected only the orders namespace to be flushed")
+	}
+}
+
+func TestNamespaceQuota(t *testing.T) {
+	cache := ttlmap.New()
+	defer cache.Close()
+
+	ns := cache.Namespace("quota", ttlmap.WithNamespaceMaxEntries(2), ttlmap.WithNamespaceMaxCost(10))
+	if err := ns.SetWithOptions("a", 1, ttlmap.WithCost(4)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := ns.SetWithOptions("b", 2, ttlmap.WithCost(7)); err != ttlmap.ErrQuotaExceeded {
+		t.Fatalf("expected cost quota to be exceeded, got %v", err)
+	}
+	if err := ns.SetWithOptions("b", 2, ttlmap.WithCost(6)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := ns.Set("c", 3, nil); err != ttlmap.ErrQuotaExceeded {
+		t.Fatalf("expected entry quota to be exceeded, got %v", err)
+	}
+	// Replacing an existing item is within quota
+	if err := ns.SetWithOptions("a", 11, ttlmap.WithCost(2)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ns.Remove("b")
+	if err := ns.Set("c", 3, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats := ns.Stats(); stats.Entries != 2 || stats.Cost != 2 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestNamespaceQuotaSharedByViews(t *testing.T) {
+	cache := ttlmap.New()
+	defer cache.Close()
+
+	first := cache.Namespace("shared", ttlmap.WithNamespaceMaxEntries(1))
+	second := cache.Namespace("shared")
+	if err := first.Set("a", 1, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := second.Set("b", 2, nil); err != ttlmap.ErrQuotaExceeded {
+		t.Fatalf("expected a second view to respect the quota, got %v", err)
+	}
+
+	// Options passed later update every view
+	cache.Namespace("shared", ttlmap.WithNamespaceMaxEntries(2))
+	if err := first.Set("b", 2, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := second.Len(); n != 2 {
+		t.Fatalf("expected 2 items, got %d", n)
+	}
+}
+
+func TestNamespaceDependencies(t *testing.T) {
+	cache := ttlmap.New()
+	defer cache.Close()
+	ns := cache.Namespace("deps")
+
+	cache.Set("parent", "root", nil)
+	if err := ns.Set("parent", 1, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := ns.SetWithOptions("child", 2, ttlmap.WithDependencies("parent")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cache.Remove("parent")
+	if !ns.Has("child") {
+		t.Fatalf("expected removing a key outside the namespace to leave the child")
+	}
+	ns.Remove("parent")
+	if ns.Has("child") {
+		t.Fatalf("expected removing the namespace parent to remove the child")
+	}
+}
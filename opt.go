@@ -4,30 +4,55 @@ import "time"
 
 type cacheOptions struct {
 	cleanupDuration      time.Duration
+	expiryBudget         time.Duration
+	backgroundCleanup    bool
 	defaultCacheDuration time.Duration
 	maxLifetime          time.Duration
 	shardCount           int
+	shardBytes           int
+	autoReshard          int
+	hasher               Hasher
+	compressThreshold    int
+	codec                Codec
 }
 
 func defaultCacheOptions() cacheOptions {
 	return cacheOptions{
 		cleanupDuration:      time.Minute,
+		expiryBudget:         5 * time.Millisecond,
+		backgroundCleanup:    true,
 		defaultCacheDuration: time.Hour,
 		maxLifetime:          365 * (24 * time.Hour),
 		shardCount:           32,
+		shardBytes:           1 << 20,
 	}
 }
 
 // CacheOption configures how we set up the cache map
 type CacheOption func(options *cacheOptions)
 
-// WithShardSize With a custom sub map shard size
+// WithShardSize With a custom sub map shard size, a CacheMap rounds it up to a power of two
 func WithShardSize(shardSize int) CacheOption {
 	return func(o *cacheOptions) {
 		o.shardCount = shardSize
 	}
 }
 
+// WithHasher Sets the hash used to pick the shard for a key, by default a randomly seeded maphash.
+// A BytesCache also indexes entries by the hash, keys with the same hash replace each other.
+func WithHasher(hasher Hasher) CacheOption {
+	return func(o *cacheOptions) {
+		o.hasher = hasher
+	}
+}
+
+// WithAutoReshard Doubles the shard count whenever a write leaves a shard holding more than maxItems items
+func WithAutoReshard(maxItems int) CacheOption {
+	return func(o *cacheOptions) {
+		o.autoReshard = maxItems
+	}
+}
+
 // WithDefaultTTL Sets the default duration for items stored
 func WithDefaultTTL(ttl time.Duration) CacheOption {
 	return func(o *cacheOptions) {
@@ -42,9 +67,45 @@ func WithCleanupDuration(ttl time.Duration) CacheOption {
 	}
 }
 
+// WithExpiryBudget Sets the maximum time each cleanup spends sweeping the shards for expired items
+func WithExpiryBudget(budget time.Duration) CacheOption {
+	return func(o *cacheOptions) {
+		o.expiryBudget = budget
+	}
+}
+
+// WithoutBackgroundCleanup Disables the cleanup goroutine, expired items are only removed when read or by Cleanup
+func WithoutBackgroundCleanup() CacheOption {
+	return func(o *cacheOptions) {
+		o.backgroundCleanup = false
+	}
+}
+
+// WithBackgroundCleanup Enables the cleanup goroutine, for use with Reconfigure
+func WithBackgroundCleanup() CacheOption {
+	return func(o *cacheOptions) {
+		o.backgroundCleanup = true
+	}
+}
+
 // WithMaxLifetime Sets the maximum amount of time an item can exist within the cache
 func WithMaxLifetime(ttl time.Duration) CacheOption {
 	return func(o *cacheOptions) {
 		o.maxLifetime = ttl
 	}
 }
+
+// WithShardBytes Sets the size of the ring buffer preallocated for each BytesCache shard
+func WithShardBytes(size int) CacheOption {
+	return func(o *cacheOptions) {
+		o.shardBytes = size
+	}
+}
+
+// WithCompression Compresses []byte and string values of at least threshold bytes with the given codec
+func WithCompression(threshold int, codec Codec) CacheOption {
+	return func(o *cacheOptions) {
+		o.compressThreshold = threshold
+		o.codec = codec
+	}
+}
@@ -0,0 +1,31 @@
+package ttlmap
+
+// Pin pins an unexpired item so it ignores its TTL and max lifetime, returning false if the key is missing
+func (m CacheMap) Pin(key string) bool {
+	return m.setPinned(key, true)
+}
+
+// Unpin unpins an item, restarting its expiry from now, returning false if the key is missing or not pinned.
+// An item past its max lifetime expires once unpinned.
+func (m CacheMap) Unpin(key string) bool {
+	return m.setPinned(key, false)
+}
+
+func (m CacheMap) setPinned(key string, pinned bool) bool {
+	shard := m.lockShard(key)
+	defer shard.unlock()
+	itm, ok := shard.live(key)
+	if !ok || itm.IsPinned() == pinned {
+		return ok && pinned
+	}
+
+	shard.stats.release(itm)
+	itm.Lock()
+	itm.pinned = pinned
+	itm.Unlock()
+	shard.stats.track(itm)
+	if !pinned {
+		itm.Touch()
+	}
+	return true
+}
@@ -0,0 +1,49 @@
+package ttlmap_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/packaged/ttlmap"
+)
+
+func TestPin(t *testing.T) {
+	cache := ttlmap.New(ttlmap.WithMaxLifetime(30*time.Millisecond), ttlmap.WithCleanupDuration(5*time.Millisecond))
+	defer cache.Close()
+
+	ttl := 10 * time.Millisecond
+	cache.SetWithOptions("pinned", 1, ttlmap.WithTTL(ttl), ttlmap.WithPin())
+	cache.Set("later", 2, &ttl)
+	if !cache.Pin("later") || cache.Pin("missing") {
+		t.Fatalf("expected Pin to succeed only for existing keys")
+	}
+	cache.Set("unpinned", 3, &ttl)
+
+	if stats := cache.Stats(); stats.Pinned != 2 {
+		t.Fatalf("expected 2 pinned items, got %d", stats.Pinned)
+	}
+
+	// Past both the TTL and max lifetime
+	time.Sleep(50 * time.Millisecond)
+	if !cache.Has("pinned") || !cache.Has("later") || cache.Has("unpinned") {
+		t.Fatalf("expected only pinned items to survive cleanup")
+	}
+
+	if !cache.Unpin("later") || cache.Unpin("later") {
+		t.Fatalf("expected Unpin to succeed once")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if cache.Has("later") {
+		t.Fatalf("expected unpinned item to expire")
+	}
+
+	cache.Remove("pinned")
+	if cache.Has("pinned") {
+		t.Fatalf("expected explicit remove to remove pinned items")
+	}
+	cache.SetWithOptions("pinned", 1, ttlmap.WithPin())
+	cache.Flush()
+	if cache.Has("pinned") || cache.Stats().Pinned != 0 {
+		t.Fatalf("expected flush to remove pinned items")
+	}
+}
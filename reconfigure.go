@@ -0,0 +1,58 @@
+package ttlmap
+
+// Reconfigure applies the options to a running cache, the change is seen by every copy of the CacheMap.
+// The default TTL, max lifetime and compression apply to items stored afterwards, use ApplyMaxLifetime to
+// re-evaluate existing items. Cleanup duration, expiry budget and background cleanup take effect immediately.
+// A new shard count starts resharding unless it is already in progress, the hasher and shard bytes can not be changed.
+func (m CacheMap) Reconfigure(opts ...CacheOption) {
+	m.cleanup.Lock()
+	defer m.cleanup.Unlock()
+
+	current := m.options.Load()
+	options := *current
+	// Auto reshard may have moved the shard count on since the options were stored
+	options.shardCount = m.ShardCount()
+	for _, opt := range opts {
+		opt(&options)
+	}
+	options.shardCount = powerOfTwo(options.shardCount)
+	options.shardBytes, options.hasher = current.shardBytes, current.hasher
+	if options.shardCount != m.ShardCount() {
+		m.Reshard(options.shardCount)
+	}
+	// Only keep the shard count actually applied, resharding may already have been in progress
+	options.shardCount = m.ShardCount()
+	m.options.Store(&options)
+
+	if options.cleanupDuration != current.cleanupDuration || options.expiryBudget != current.expiryBudget ||
+		options.backgroundCleanup != current.backgroundCleanup {
+		m.cleanup.restart(&options)
+	}
+}
+
+// ApplyMaxLifetime moves the deadline of existing items to their creation time plus the current max lifetime,
+// returning the number of items removed as a result. Items stored with their own max lifetime or
+// expiry time are left unchanged.
+func (m CacheMap) ApplyMaxLifetime() int {
+	maxLifetime := m.options.Load().maxLifetime
+	removed := 0
+	t := m.shards.pin()
+	defer m.shards.unpin()
+	for _, shard := range t.all {
+		shard.Lock()
+		for key, itm := range shard.items {
+			if !itm.defaultLifetime {
+				continue
+			}
+			itm.Lock()
+			itm.deadline = itm.created.Add(maxLifetime)
+			itm.Unlock()
+			if itm.Expired() {
+				shard.remove(key, itm.expiryReason())
+				removed++
+			}
+		}
+		shard.unlock()
+	}
+	return removed
+}
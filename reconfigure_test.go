@@ -0,0 +1,66 @@
+package ttlmap_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/packaged/ttlmap"
+)
+
+func TestReconfigureDefaults(t *testing.T) {
+	cache := ttlmap.New(ttlmap.WithDefaultTTL(time.Hour))
+	defer cache.Close()
+	copied := cache
+
+	cache.Reconfigure(ttlmap.WithDefaultTTL(time.Minute))
+	copied.Set("k", 1, nil)
+	itm, ok := cache.GetItem("k")
+	if !ok {
+		t.Fatalf("expected item to be stored")
+	}
+	if ttl := time.Until(itm.GetExpiry()); ttl > time.Minute {
+		t.Fatalf("expected the new default TTL to apply to copies, got %v", ttl)
+	}
+}
+
+func TestReconfigureCleanup(t *testing.T) {
+	cache := ttlmap.New(ttlmap.WithoutBackgroundCleanup())
+	defer cache.Close()
+
+	var removed int32
+	short := time.Millisecond
+	for _, key := range []string{"a", "b", "c"} {
+		cache.SetWithCleanup(key, 1, &short, func(item *ttlmap.Item) { atomic.AddInt32(&removed, 1) })
+	}
+	time.Sleep(10 * time.Millisecond)
+	if atomic.LoadInt32(&removed) != 0 {
+		t.Fatalf("expected no background cleanup")
+	}
+
+	cache.Reconfigure(ttlmap.WithBackgroundCleanup(), ttlmap.WithCleanupDuration(5*time.Millisecond))
+	time.Sleep(30 * time.Millisecond)
+	if n := atomic.LoadInt32(&removed); n != 3 {
+		t.Fatalf("expected background cleanup to remove expired items, removed %d", n)
+	}
+}
+
+func TestApplyMaxLifetime(t *testing.T) {
+	cache := ttlmap.New(ttlmap.WithMaxLifetime(time.Hour))
+	defer cache.Close()
+
+	cache.Set("default", 1, nil)
+	cache.SetWithOptions("own", 2, ttlmap.WithItemMaxLifetime(time.Hour))
+	time.Sleep(5 * time.Millisecond)
+
+	cache.Reconfigure(ttlmap.WithMaxLifetime(time.Millisecond))
+	if !cache.Has("default") {
+		t.Fatalf("expected existing items to keep their deadline until re-evaluated")
+	}
+	if removed := cache.ApplyMaxLifetime(); removed != 1 {
+		t.Fatalf("expected one item to be removed, got %d", removed)
+	}
+	if cache.Has("default") || !cache.Has("own") {
+		t.Fatalf("expected only the item using the cache max lifetime to be removed")
+	}
+}
@@ -0,0 +1,37 @@
+package ttlmap
+
+// RemovalReason describes why an item was removed from the cache
+type RemovalReason int
+
+const (
+	// RemovedDeleted the item was removed explicitly
+	RemovedDeleted RemovalReason = iota
+	// RemovedExpired the item passed its expiry time or max lifetime
+	RemovedExpired
+	// RemovedMaxReads the item was read the max number of times
+	RemovedMaxReads
+	// RemovedIdle the item was not read within its max idle time
+	RemovedIdle
+	// RemovedDependency an item it depends on was removed, replaced or expired
+	RemovedDependency
+	// RemovedRejected the item could not be stored, such as a value a DiskStore can not serialize
+	RemovedRejected
+)
+
+func (r RemovalReason) String() string {
+	switch r {
+	case RemovedDeleted:
+		return "deleted"
+	case RemovedExpired:
+		return "expired"
+	case RemovedMaxReads:
+		return "max reads"
+	case RemovedIdle:
+		return "idle"
+	case RemovedDependency:
+		return "dependency"
+	case RemovedRejected:
+		return "rejected"
+	}
+	return "unknown"
+}
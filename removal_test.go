@@ -0,0 +1,53 @@
+package ttlmap_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/packaged/ttlmap"
+)
+
+func TestMaxReadsAndIdle(t *testing.T) {
+	cache := ttlmap.New(ttlmap.WithCleanupDuration(5 * time.Millisecond))
+	defer cache.Close()
+
+	reasons := map[string]ttlmap.RemovalReason{}
+	record := func(key string) ttlmap.ItemOption {
+		return ttlmap.WithOnDelete(func(item *ttlmap.Item) { reasons[key] = item.RemovalReason() })
+	}
+
+	cache.SetWithOptions("token", "secret", ttlmap.WithMaxReads(2), record("token"))
+	if _, ok := cache.Get("token"); !ok {
+		t.Fatalf("expected first read to succeed")
+	}
+	if v, err := ttlmap.Fetch[string](cache, "token", func(string) (string, error) { return "fresh", nil }); err != nil || v != "secret" {
+		t.Fatalf("expected second read to succeed, got %v", v)
+	}
+	if _, ok := cache.Get("token"); ok {
+		t.Fatalf("expected reads to be exhausted")
+	}
+	if reasons["token"] != ttlmap.RemovedMaxReads {
+		t.Fatalf("expected max reads removal reason, got %v", reasons["token"])
+	}
+
+	cache.SetWithOptions("link", "url", ttlmap.WithMaxIdle(20*time.Millisecond), record("link"))
+	for i := 0; i < 4; i++ {
+		time.Sleep(10 * time.Millisecond)
+		if _, ok := cache.TouchGet("link", false); !ok {
+			t.Fatalf("expected reads within the idle time to succeed")
+		}
+	}
+	time.Sleep(40 * time.Millisecond)
+	if cache.Has("link") {
+		t.Fatalf("expected idle item to expire")
+	}
+	if reasons["link"] != ttlmap.RemovedIdle {
+		t.Fatalf("expected idle removal reason, got %v", reasons["link"])
+	}
+
+	cache.SetWithOptions("removed", 1, record("removed"))
+	cache.Remove("removed")
+	if reasons["removed"] != ttlmap.RemovedDeleted {
+		t.Fatalf("expected deleted removal reason, got %v", reasons["removed"])
+	}
+}
@@ -0,0 +1,283 @@
+package ttlmap
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// shardTable is the set of shards keys are spread over, it is replaced rather than modified
+type shardTable struct {
+	shards []*CacheMapShared
+	old    []*CacheMapShared // shards being migrated from while resharding
+	all    []*CacheMapShared // old then current shards, every shard which may hold items
+}
+
+func newShardTable(shards, old []*CacheMapShared) *shardTable {
+	all := make([]*CacheMapShared, 0, len(old)+len(shards))
+	all = append(all, old...)
+	return &shardTable{shards: shards, old: old, all: append(all, shards...)}
+}
+
+// shardState holds the shard table, and migrates items when the shard count changes.
+// Operations lock the shard they expect to hold a key, and move on if it was migrated while they waited.
+// Iterating over every shard pins the table by holding the read lock, migration only takes the write lock with
+// TryLock so it never blocks them.
+type shardState struct {
+	sync.RWMutex
+	table     atomic.Pointer[shardTable]
+	migrating atomic.Bool
+	newShard  func() *CacheMapShared
+	hash      Hasher
+
+	mu       sync.Mutex // guards starting and stopping migration
+	shutdown chan struct{}
+	done     chan struct{}
+	closed   bool
+}
+
+func newShardState(count int, hash Hasher, newShard func() *CacheMapShared) *shardState {
+	shards := make([]*CacheMapShared, count)
+	for i := range shards {
+		shards[i] = newShard()
+	}
+	s := &shardState{newShard: newShard, hash: hash, shutdown: make(chan struct{})}
+	s.table.Store(newShardTable(shards, nil))
+	return s
+}
+
+// shard returns the shard for the key within shards
+func (s *shardState) shard(shards []*CacheMapShared, key string) *CacheMapShared {
+	return shards[s.index(len(shards), key)]
+}
+
+// index returns the index of the shard for the key, masking the hash as the shard count is always a power of two
+func (s *shardState) index(count int, key string) int {
+	return int(s.hash(key) & uint64(count-1))
+}
+
+// Groups the keys by the shard for each key within shards
+func (s *shardState) groupKeys(shards []*CacheMapShared, keys []string) map[*CacheMapShared][]string {
+	groups := make(map[*CacheMapShared][]string)
+	for _, key := range keys {
+		shard := s.shard(shards, key)
+		groups[shard] = append(groups[shard], key)
+	}
+	return groups
+}
+
+// powerOfTwo rounds the shard count up to a power of two
+func powerOfTwo(count int) int {
+	n := 1
+	for n < count {
+		n <<= 1
+	}
+	return n
+}
+
+// locate locks and returns the shard holding the key
+func (s *shardState) locate(key string, lock, unlock func(*CacheMapShared)) *CacheMapShared {
+	for {
+		t := s.table.Load()
+		if t.old != nil {
+			shard := s.shard(t.old, key)
+			lock(shard)
+			if !shard.migrated {
+				return shard
+			}
+			unlock(shard)
+		}
+		shard := s.shard(t.shards, key)
+		lock(shard)
+		if !shard.migrated {
+			return shard
+		}
+		// Resharding started and moved this shard since the table was loaded
+		unlock(shard)
+	}
+}
+
+// lockEach calls fn with the keys grouped by the shard holding them, with each shard locked in turn
+func (s *shardState) lockEach(keys []string, lock, unlock func(*CacheMapShared), fn func(*CacheMapShared, []string)) {
+	for len(keys) > 0 {
+		t := s.table.Load()
+		for _, shards := range [][]*CacheMapShared{t.old, t.shards} {
+			if len(shards) == 0 || len(keys) == 0 {
+				continue
+			}
+			var moved []string
+			for shard, shardKeys := range s.groupKeys(shards, keys) {
+				lock(shard)
+				if shard.migrated {
+					unlock(shard)
+					moved = append(moved, shardKeys...)
+					continue
+				}
+				fn(shard, shardKeys)
+				unlock(shard)
+			}
+			keys = moved
+		}
+	}
+}
+
+// lockKeys write locks every shard holding the keys in index order, old shards before new shards.
+// Returns the shard holding each key, and the locked shards which may include migrated shards.
+func (s *shardState) lockKeys(keys []string) (map[string]*CacheMapShared, []*CacheMapShared) {
+	for {
+		t := s.table.Load()
+		owners := make(map[string]*CacheMapShared, len(keys))
+		var locked []*CacheMapShared
+		remaining := keys
+		for _, shards := range [][]*CacheMapShared{t.old, t.shards} {
+			if len(shards) == 0 || len(remaining) == 0 {
+				continue
+			}
+			groups := s.groupKeys(shards, remaining)
+			remaining = nil
+			for _, shard := range shards {
+				shardKeys, ok := groups[shard]
+				if !ok {
+					continue
+				}
+				shard.Lock()
+				locked = append(locked, shard)
+				if shard.migrated {
+					remaining = append(remaining, shardKeys...)
+					continue
+				}
+				for _, key := range shardKeys {
+					owners[key] = shard
+				}
+			}
+		}
+		if len(remaining) == 0 {
+			return owners, locked
+		}
+		// The table moved on while locking, start again
+		for _, shard := range locked {
+			shard.Unlock()
+		}
+	}
+}
+
+// pin keeps the shard table unchanged until unpin is called
+func (s *shardState) pin() *shardTable {
+	s.RLock()
+	return s.table.Load()
+}
+
+func (s *shardState) unpin() {
+	s.RUnlock()
+}
+
+// acquire takes the write lock without blocking pinned operations, returning false if the cache was closed
+func (s *shardState) acquire() bool {
+	for !s.TryLock() {
+		select {
+		case <-s.shutdown:
+			return false
+		case <-time.After(time.Millisecond):
+		}
+	}
+	return true
+}
+
+// start begins migrating to count shards rounded up to a power of two, returning false if already migrating or closed
+func (s *shardState) start(count int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count = powerOfTwo(count)
+	if s.closed || s.migrating.Load() || count == len(s.table.Load().shards) {
+		return false
+	}
+	s.migrating.Store(true)
+	s.done = make(chan struct{})
+	go s.migrate(count, s.done)
+	return true
+}
+
+// migrate moves the items of one old shard at a time into the new shards, keeping their expiry and metadata
+func (s *shardState) migrate(count int, done chan struct{}) {
+	defer close(done)
+	defer s.migrating.Store(false)
+
+	shards := make([]*CacheMapShared, count)
+	for i := range shards {
+		shards[i] = s.newShard()
+	}
+	if !s.acquire() {
+		return
+	}
+	t := newShardTable(shards, s.table.Load().shards)
+	s.table.Store(t)
+	s.Unlock()
+
+	for _, old := range t.old {
+		if !s.acquire() {
+			return
+		}
+		old.Lock()
+		for key, itm := range old.items {
+			shard := s.shard(shards, key)
+			shard.Lock()
+			shard.store(key, itm)
+			shard.tag(key, itm)
+			shard.Unlock()
+		}
+		old.items = make(map[string]*Item)
+		old.tags = make(map[string]map[string]struct{})
+		old.keys, old.sweep = nil, 0
+		old.migrated = true
+		old.Unlock()
+		s.Unlock()
+		// Let waiting operations through between shards
+		runtime.Gosched()
+	}
+
+	if !s.acquire() {
+		return
+	}
+	s.table.Store(newShardTable(shards, nil))
+	s.Unlock()
+}
+
+// close stops any migration in progress and waits for it to exit, the cache remains usable mid migration
+func (s *shardState) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	close(s.shutdown)
+	if s.done != nil {
+		<-s.done
+	}
+}
+
+// Reshard changes the number of shards while the cache remains in use. Items are migrated one old shard at a
+// time in the background, keeping their expiry, and operations on an old shard wait while it is migrated.
+// The count is rounded up to a power of two. Returns false if that is the current count, or resharding is
+// already in progress.
+func (m CacheMap) Reshard(count int) bool {
+	return m.shards.start(count)
+}
+
+// Resharding returns true while items are being migrated to a new shard count
+func (m CacheMap) Resharding() bool {
+	return m.shards.migrating.Load()
+}
+
+// ShardCount returns the number of shards, the new count while resharding
+func (m CacheMap) ShardCount() int {
+	return len(m.shards.table.Load().shards)
+}
+
+// grow doubles the shard count when the shard has more items than the auto reshard limit, the shard must be locked
+func (m CacheMap) grow(shard *CacheMapShared) {
+	if limit := m.options.Load().autoReshard; limit > 0 && len(shard.items) > limit && !m.shards.migrating.Load() {
+		m.Reshard(2 * m.ShardCount())
+	}
+}
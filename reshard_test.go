@@ -0,0 +1,205 @@
+package ttlmap_test
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/packaged/ttlmap"
+)
+
+func waitForReshard(t *testing.T, cache ttlmap.CacheMap) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for cache.Resharding() {
+		if time.Now().After(deadline) {
+			t.Fatalf("resharding did not complete")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestReshard(t *testing.T) {
+	cache := ttlmap.New(ttlmap.WithShardSize(4))
+	defer cache.Close()
+
+	expiries := make(map[string]time.Time)
+	for i := 0; i < 1000; i++ {
+		key := "k" + strconv.Itoa(i)
+		ttl := time.Duration(i+1) * time.Minute
+		cache.SetWithOptions(key, i, ttlmap.WithTTL(ttl), ttlmap.WithTags("all"))
+		expiries[key] = *cache.GetExpiry(key)
+	}
+
+	// Keep reading and writing while items migrate
+	var wg sync.WaitGroup
+	var misses int32
+	stop := make(chan struct{})
+	defer func() {
+		close(stop)
+		wg.Wait()
+	}()
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if _, ok := cache.TouchGet("k"+strconv.Itoa(i%1000), false); !ok {
+					atomic.AddInt32(&misses, 1)
+				}
+				cache.Set("w"+strconv.Itoa(w), i, nil)
+				_ = cache.Txn([]string{"k1", "k999", "w" + strconv.Itoa(w)}, func(tx *ttlmap.Tx) error {
+					v, _ := tx.Get("w" + strconv.Itoa(w))
+					tx.Set("w"+strconv.Itoa(w), v, nil)
+					return nil
+				})
+				cache.MGet([]string{"w0", "w" + strconv.Itoa(w)})
+			}
+		}(w)
+	}
+
+	if !cache.Reshard(32) {
+		t.Fatalf("expected resharding to start")
+	}
+	if cache.Reshard(64) {
+		t.Fatalf("expected resharding to be refused while in progress")
+	}
+	waitForReshard(t, cache)
+
+	if n := atomic.LoadInt32(&misses); n != 0 {
+		t.Fatalf("expected every item to be found while resharding, %d misses", n)
+	}
+	if n := cache.ShardCount(); n != 32 {
+		t.Fatalf("expected 32 shards, got %d", n)
+	}
+	if n := cache.Len(); n != 1004 {
+		t.Fatalf("expected 1004 items, got %d", n)
+	}
+	for key, expiry := range expiries {
+		if v, ok := cache.TouchGet(key, false); !ok || strconv.Itoa(v.(int)) != key[1:] {
+			t.Fatalf("expected %s to keep its value", key)
+		}
+		if got := cache.GetExpiry(key); got == nil || !got.Equal(expiry) {
+			t.Fatalf("expected %s to keep its expiry", key)
+		}
+	}
+	if removed := cache.InvalidateTag("all"); removed != 1000 {
+		t.Fatalf("expected tags to move with their items, removed %d", removed)
+	}
+}
+
+func TestAutoReshard(t *testing.T) {
+	cache := ttlmap.New(ttlmap.WithShardSize(1), ttlmap.WithAutoReshard(100))
+	defer cache.Close()
+
+	for i := 0; i < 1000; i++ {
+		cache.Set("k"+strconv.Itoa(i), i, nil)
+		waitForReshard(t, cache)
+	}
+	if n := cache.ShardCount(); n < 8 {
+		t.Fatalf("expected the shard count to grow, got %d", n)
+	}
+	if n := cache.Len(); n != 1000 {
+		t.Fatalf("expected 1000 items, got %d", n)
+	}
+}
+
+func TestReconfigureShardCount(t *testing.T) {
+	cache := ttlmap.New(ttlmap.WithShardSize(2))
+	defer cache.Close()
+	cache.Set("k", 1, nil)
+
+	cache.Reconfigure(ttlmap.WithShardSize(16))
+	waitForReshard(t, cache)
+	if n := cache.ShardCount(); n != 16 {
+		t.Fatalf("expected 16 shards, got %d", n)
+	}
+	if !cache.Has("k") {
+		t.Fatalf("expected item to survive resharding")
+	}
+}
+
+func TestReconfigureShardCountAfterAutoReshard(t *testing.T) {
+	cache := ttlmap.New(ttlmap.WithShardSize(4), ttlmap.WithAutoReshard(10))
+	defer cache.Close()
+
+	for i := 0; i < 500; i++ {
+		cache.Set("k"+strconv.Itoa(i), i, nil)
+		waitForReshard(t, cache)
+	}
+	if n := cache.ShardCount(); n <= 4 {
+		t.Fatalf("expected the shard count to grow, got %d", n)
+	}
+
+	cache.Reconfigure(ttlmap.WithDefaultTTL(time.Minute))
+	if !cache.Resharding() && cache.ShardCount() <= 4 {
+		t.Fatalf("expected unrelated options to keep the grown shard count")
+	}
+	cache.Reconfigure(ttlmap.WithAutoReshard(0), ttlmap.WithShardSize(4))
+	waitForReshard(t, cache)
+	if n := cache.ShardCount(); n != 4 {
+		t.Fatalf("expected 4 shards, got %d", n)
+	}
+	if n := cache.Len(); n != 500 {
+		t.Fatalf("expected 500 items, got %d", n)
+	}
+}
+
+func TestGetShardWithinCleanup(t *testing.T) {
+	cache := ttlmap.New()
+	defer cache.Close()
+
+	var shard *ttlmap.CacheMapShared
+	cache.SetWithCleanup("k", 1, nil, func(*ttlmap.Item) { shard = cache.GetShard("k") })
+	done := make(chan struct{})
+	go func() {
+		cache.Remove("k")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("expected GetShard not to lock the shard held by the cleanup")
+	}
+	if shard != cache.GetShard("k") {
+		t.Fatalf("expected the shard holding the key")
+	}
+}
+
+func TestAutoReshardOnEveryWrite(t *testing.T) {
+	writes := map[string]func(cache ttlmap.CacheMap, key string){
+		"GetOrSet":  func(cache ttlmap.CacheMap, key string) { cache.GetOrSet(key, 1, nil) },
+		"Increment": func(cache ttlmap.CacheMap, key string) { cache.Increment(key, nil) },
+		"Fetch": func(cache ttlmap.CacheMap, key string) {
+			ttlmap.Fetch(cache, key, func(string) (int, error) { return 1, nil })
+		},
+		"Compute": func(cache ttlmap.CacheMap, key string) {
+			cache.Compute(key, func(*ttlmap.Item, bool) (interface{}, time.Duration, ttlmap.Action) {
+				return 1, 0, ttlmap.ActionReplace
+			})
+		},
+		"Txn": func(cache ttlmap.CacheMap, key string) {
+			cache.Txn([]string{key}, func(tx *ttlmap.Tx) error { return tx.Set(key, 1, nil) })
+		},
+	}
+	for name, write := range writes {
+		t.Run(name, func(t *testing.T) {
+			cache := ttlmap.New(ttlmap.WithShardSize(1), ttlmap.WithAutoReshard(10))
+			defer cache.Close()
+			for i := 0; i < 1000; i++ {
+				write(cache, "k"+strconv.Itoa(i))
+				waitForReshard(t, cache)
+			}
+			if n := cache.ShardCount(); n < 8 {
+				t.Fatalf("expected the shard count to grow, got %d", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package ttlmap
+
+import "sync/atomic"
+
+// Stats holds the counters for a CacheMap
+type Stats struct {
+	Hits            uint64
+	Misses          uint64
+	Pinned          int64 // live pinned items
+	CompressedItems int64 // live items holding a compressed value
+	RawBytes        int64 // uncompressed size of the compressed items
+	CompressedBytes int64 // compressed size of the compressed items
+}
+
+type cacheStats struct {
+	hits            atomic.Uint64
+	misses          atomic.Uint64
+	pinned          atomic.Int64
+	compressedItems atomic.Int64
+	rawBytes        atomic.Int64
+	compressedBytes atomic.Int64
+}
+
+// Stats returns the counters for the cache
+func (m CacheMap) Stats() Stats {
+	return m.stats.snapshot()
+}
+
+func (s *cacheStats) snapshot() Stats {
+	return Stats{
+		Hits:            s.hits.Load(),
+		Misses:          s.misses.Load(),
+		Pinned:          s.pinned.Load(),
+		CompressedItems: s.compressedItems.Load(),
+		RawBytes:        s.rawBytes.Load(),
+		CompressedBytes: s.compressedBytes.Load(),
+	}
+}
+
+// lookup records a cache hit or miss
+func (s *cacheStats) lookup(hit bool) {
+	if hit {
+		s.hits.Add(1)
+	} else {
+		s.misses.Add(1)
+	}
+}
+
+// track records an item being stored
+func (s *cacheStats) track(itm *Item) {
+	s.trackSign(itm, 1)
+}
+
+// release records an item being removed or replaced
+func (s *cacheStats) release(itm *Item) {
+	s.trackSign(itm, -1)
+}
+
+func (s *cacheStats) trackSign(itm *Item, sign int64) {
+	if s == nil {
+		return
+	}
+	if itm.pinned {
+		s.pinned.Add(sign)
+	}
+	if cv, ok := itm.data.(*compressedValue); ok {
+		s.compressedItems.Add(sign)
+		s.rawBytes.Add(sign * int64(cv.rawSize))
+		s.compressedBytes.Add(sign * int64(len(cv.data)))
+	}
+}
+
+// ShardBalance reports how the items are spread over the shards
+type ShardBalance struct {
+	Entries []int // items held by each shard, including expired items not yet cleaned up
+	Min     int
+	Max     int
+	Mean    float64
+	Skew    float64 // Max divided by Mean, 1 when evenly spread
+}
+
+// ShardBalance returns the number of items held by each shard.
+// While resharding, items yet to be migrated are counted against the new shard they will move to.
+func (m CacheMap) ShardBalance() ShardBalance {
+	t := m.shards.pin()
+	defer m.shards.unpin()
+
+	b := ShardBalance{Entries: make([]int, len(t.shards))}
+	for _, shard := range t.old {
+		shard.RLock()
+		for key := range shard.items {
+			b.Entries[m.shards.index(len(t.shards), key)]++
+		}
+		shard.RUnlock()
+	}
+	total := 0
+	for i, shard := range t.shards {
+		shard.RLock()
+		b.Entries[i] += len(shard.items)
+		shard.RUnlock()
+		total += b.Entries[i]
+	}
+
+	b.Min, b.Max = b.Entries[0], b.Entries[0]
+	for _, n := range b.Entries {
+		if n < b.Min {
+			b.Min = n
+		}
+		if n > b.Max {
+			b.Max = n
+		}
+	}
+	b.Mean = float64(total) / float64(len(b.Entries))
+	if b.Mean > 0 {
+		b.Skew = float64(b.Max) / b.Mean
+	}
+	return b
+}
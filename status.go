@@ -0,0 +1,62 @@
+package ttlmap
+
+import "time"
+
+// Freshness describes the state of an item returned by GetWithStatus
+type Freshness int
+
+const (
+	// Missing the item does not exist, or has no reads remaining
+	Missing Freshness = iota
+	// Fresh the item has not expired
+	Fresh
+	// Stale the item has expired but not yet been cleaned up
+	Stale
+)
+
+func (f Freshness) String() string {
+	switch f {
+	case Fresh:
+		return "fresh"
+	case Stale:
+		return "stale"
+	}
+	return "missing"
+}
+
+// ItemStatus is the result of GetWithStatus
+type ItemStatus struct {
+	Value     interface{}
+	Freshness Freshness
+	Age       time.Duration // time since the item was stored
+	TTL       time.Duration // time until the item expires, negative once stale and zero for pinned items
+}
+
+// GetWithStatus returns the value for the key with its freshness, including expired values not yet cleaned up.
+// The item is not touched, and the read is not counted against the item.
+func (m CacheMap) GetWithStatus(key string) ItemStatus {
+	shard := m.rlockShard(key)
+	defer shard.RUnlock()
+	itm, ok := shard.items[key]
+	if !ok || itm.exhausted() {
+		return ItemStatus{Freshness: Missing}
+	}
+
+	now := time.Now()
+	status := ItemStatus{Value: itm.GetValue(), Freshness: Fresh, Age: now.Sub(itm.GetCreated())}
+	if itm.IsPinned() {
+		return status
+	}
+
+	itm.RLock()
+	expiry := itm.GetExpiry()
+	if itm.deadline.Before(expiry) {
+		expiry = itm.deadline
+	}
+	itm.RUnlock()
+	status.TTL = expiry.Sub(now)
+	if status.TTL < 0 {
+		status.Freshness = Stale
+	}
+	return status
+}
@@ -0,0 +1,34 @@
+package ttlmap_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/packaged/ttlmap"
+)
+
+func TestGetWithStatus(t *testing.T) {
+	cache := ttlmap.New()
+	defer cache.Close()
+
+	if status := cache.GetWithStatus("missing"); status.Freshness != ttlmap.Missing || status.Value != nil {
+		t.Fatalf("expected missing status, got %+v", status)
+	}
+
+	ttl := 20 * time.Millisecond
+	cache.Set("k", "v", &ttl)
+	status := cache.GetWithStatus("k")
+	if status.Freshness != ttlmap.Fresh || status.Value != "v" || status.TTL <= 0 || status.TTL > ttl {
+		t.Fatalf("expected fresh status, got %+v", status)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	status = cache.GetWithStatus("k")
+	if status.Freshness != ttlmap.Stale || status.Value != "v" || status.TTL >= 0 || status.Age < 30*time.Millisecond {
+		t.Fatalf("expected stale status, got %+v", status)
+	}
+	// Reading the status does not touch the item
+	if _, ok := cache.Get("k"); ok {
+		t.Fatalf("expected item to remain expired")
+	}
+}
@@ -0,0 +1,58 @@
+package ttlmap
+
+// InvalidateTag removes every item with the given tag, returning the number of items removed
+func (m CacheMap) InvalidateTag(tag string) int {
+	removed := 0
+	t := m.shards.pin()
+	defer m.shards.unpin()
+	for _, shard := range t.all {
+		shard.Lock()
+		for key := range shard.tags[tag] {
+			shard.remove(key, RemovedDeleted)
+			removed++
+		}
+		shard.unlock()
+	}
+	return removed
+}
+
+// Adds the key to the tag index for each of the item tags, and its namespace
+func (ms *CacheMapShared) tag(key string, itm *Item) {
+	for _, tag := range itm.tags {
+		ms.indexTag(tag, key)
+	}
+	if itm.ns != nil {
+		ms.indexTag(itm.ns.prefix, key)
+	}
+}
+
+// Removes the key from the tag index for each of the item tags, and its namespace
+func (ms *CacheMapShared) untag(key string, itm *Item) {
+	for _, tag := range itm.tags {
+		ms.unindexTag(tag, key)
+	}
+	if itm.ns != nil {
+		ms.unindexTag(itm.ns.prefix, key)
+	}
+}
+
+func (ms *CacheMapShared) indexTag(tag, key string) {
+	if ms.tags == nil {
+		ms.tags = make(map[string]map[string]struct{})
+	}
+	keys, ok := ms.tags[tag]
+	if !ok {
+		keys = make(map[string]struct{})
+		ms.tags[tag] = keys
+	}
+	keys[key] = struct{}{}
+}
+
+func (ms *CacheMapShared) unindexTag(tag, key string) {
+	if keys, ok := ms.tags[tag]; ok {
+		delete(keys, key)
+		if len(keys) == 0 {
+			delete(ms.tags, tag)
+		}
+	}
+}
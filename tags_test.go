@@ -0,0 +1,45 @@
+package ttlmap_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/packaged/ttlmap"
+)
+
+func TestInvalidateTag(t *testing.T) {
+	cache := ttlmap.New(ttlmap.WithCleanupDuration(5 * time.Millisecond))
+	defer cache.Close()
+
+	removed := 0
+	onDelete := ttlmap.WithOnDelete(func(item *ttlmap.Item) { removed++ })
+	cache.SetWithOptions("t1:a", 1, ttlmap.WithTags("tenant:1"), onDelete)
+	cache.SetWithOptions("t1:b", 2, ttlmap.WithTags("tenant:1", "kind:b"), onDelete)
+	cache.SetWithOptions("t2:a", 3, ttlmap.WithTags("tenant:2"), onDelete)
+	cache.SetWithOptions("t1:c", 4, ttlmap.WithTags("tenant:1"), ttlmap.WithTTL(10*time.Millisecond))
+
+	// Replacing an item without tags drops it from the tag index
+	cache.SetWithOptions("t1:b", 5, ttlmap.WithTags("kind:b"))
+	// Expired items are dropped from the tag index by cleanup
+	time.Sleep(30 * time.Millisecond)
+
+	if n := cache.InvalidateTag("tenant:1"); n != 1 {
+		t.Fatalf("expected 1 item invalidated, got %d", n)
+	}
+	if removed != 1 {
+		t.Fatalf("expected removal callback to fire")
+	}
+	if cache.Has("t1:a") || !cache.Has("t1:b") || !cache.Has("t2:a") {
+		t.Fatalf("expected only tenant:1 items to be invalidated")
+	}
+
+	itm, _ := cache.GetItem("t1:b")
+	if tags := itm.GetTags(); len(tags) != 1 || tags[0] != "kind:b" {
+		t.Fatalf("unexpected tags %v", tags)
+	}
+
+	cache.Flush()
+	if n := cache.InvalidateTag("tenant:2"); n != 0 {
+		t.Fatalf("expected flush to clear the tag index, got %d", n)
+	}
+}
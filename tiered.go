@@ -0,0 +1,188 @@
+package ttlmap
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Store is a cache tier that items can be demoted into, CacheMap satisfies Store
+type Store interface {
+	Get(key string) (interface{}, bool)
+	SetWithCleanup(key string, value interface{}, duration *time.Duration, cleanup func(*Item))
+	Remove(key string)
+}
+
+// TierStats holds the lookup counters for a single tier
+type TierStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// TieredStats holds the counters for a Tiered cache
+type TieredStats struct {
+	L1         TierStats
+	L2         TierStats
+	Promotions uint64
+	Demotions  uint64
+}
+
+// Tiered is a two level cache with a small hot CacheMap in front of a larger secondary Store.
+// Lookups missing L1 consult L2 and promote hits back into L1, items expiring out of L1 are demoted into L2.
+type Tiered struct {
+	l1 CacheMap
+	l2 Store
+
+	mu      sync.Mutex
+	entries map[string]*tieredEntry
+
+	l1Hits     atomic.Uint64
+	l1Misses   atomic.Uint64
+	l2Hits     atomic.Uint64
+	l2Misses   atomic.Uint64
+	promotions atomic.Uint64
+	demotions  atomic.Uint64
+}
+
+// tieredEntry tracks which tier currently owns a key, so the user cleanup only fires once the key leaves both tiers.
+// The TTL, deadline and value type of a demoted item are kept so they are restored on promotion.
+type tieredEntry struct {
+	tier     int
+	removed  bool
+	cleanup  func(*Item)
+	ttl      time.Duration
+	deadline time.Time
+	typ      reflect.Type // nil when the value was written to L2 directly
+}
+
+// NewTiered creates a tiered cache over the given tiers
+func NewTiered(l1 CacheMap, l2 Store) *Tiered {
+	return &Tiered{l1: l1, l2: l2, entries: make(map[string]*tieredEntry)}
+}
+
+// Set sets the given value into L1 under the specified key
+func (t *Tiered) Set(key string, value interface{}, duration *time.Duration) {
+	t.SetWithCleanup(key, value, duration, nil)
+}
+
+// SetWithCleanup sets the given value into L1, cleanup is called once the item leaves both tiers
+func (t *Tiered) SetWithCleanup(key string, value interface{}, duration *time.Duration, cleanup func(*Item)) {
+	e := &tieredEntry{tier: 1, cleanup: cleanup}
+	t.mu.Lock()
+	t.entries[key] = e
+	t.mu.Unlock()
+
+	t.l1.SetWithCleanup(key, value, duration, t.tierCleanup(key, e, 1))
+	// Drop any stale copy, its cleanup is ignored as the entry has been replaced
+	t.l2.Remove(key)
+}
+
+// Get retrieves an item from L1, falling back to L2 and promoting the value into L1 when found
+func (t *Tiered) Get(key string) (interface{}, bool) {
+	if value, ok := t.l1.Get(key); ok {
+		t.l1Hits.Add(1)
+		return value, true
+	}
+	t.l1Misses.Add(1)
+
+	value, ok := t.l2.Get(key)
+	if !ok {
+		t.l2Misses.Add(1)
+		return nil, false
+	}
+	t.l2Hits.Add(1)
+
+	t.mu.Lock()
+	e, ok := t.entries[key]
+	if data, isBytes := value.([]byte); ok && isBytes {
+		// L2 may only hold bytes, such as a DiskStore
+		value = diskDecode(data, e.typ)
+	}
+	if ok && (e.tier != 2 || e.removed) {
+		// The key was set, removed or promoted since L2 was read, so the value must not replace it
+		t.mu.Unlock()
+		return value, true
+	}
+	if !ok {
+		// The value was written to L2 directly
+		e = &tieredEntry{}
+		t.entries[key] = e
+	}
+	e.tier = 1
+	t.mu.Unlock()
+
+	o := itemOptions{onDelete: t.tierCleanup(key, e, 1)}
+	if e.ttl > 0 {
+		o.duration, o.deadline = &e.ttl, &e.deadline
+	}
+	// A concurrent Set may have stored a newer value in L1 since the entry was checked
+	if _, loaded := t.l1.getOrSet(key, value, o); !loaded {
+		t.promotions.Add(1)
+	}
+	t.l2.Remove(key)
+	return value, true
+}
+
+// Remove removes the key from both tiers
+func (t *Tiered) Remove(key string) {
+	t.mu.Lock()
+	e, ok := t.entries[key]
+	if ok {
+		e.removed = true
+	}
+	t.mu.Unlock()
+
+	t.l1.Remove(key)
+	t.l2.Remove(key)
+
+	if ok {
+		// Neither tier held the item any longer, so no cleanup fired
+		t.mu.Lock()
+		if t.entries[key] == e {
+			delete(t.entries, key)
+		}
+		t.mu.Unlock()
+	}
+}
+
+// Stats returns the counters for each tier
+func (t *Tiered) Stats() TieredStats {
+	return TieredStats{
+		L1:         TierStats{Hits: t.l1Hits.Load(), Misses: t.l1Misses.Load()},
+		L2:         TierStats{Hits: t.l2Hits.Load(), Misses: t.l2Misses.Load()},
+		Promotions: t.promotions.Load(),
+		Demotions:  t.demotions.Load(),
+	}
+}
+
+// tierCleanup builds the removal callback for an entry placed in the given tier.
+// Items leaving L1 before their deadline are demoted into L2 with their own TTL, capped at the time left before
+// their deadline.
+func (t *Tiered) tierCleanup(key string, e *tieredEntry, tier int) func(*Item) {
+	return func(itm *Item) {
+		t.mu.Lock()
+		if t.entries[key] != e || e.tier != tier {
+			// The key has been replaced, or moved to the other tier
+			t.mu.Unlock()
+			return
+		}
+		if remaining := time.Until(itm.GetDeadline()); tier == 1 && !e.removed && remaining > 0 {
+			e.tier, e.ttl, e.deadline, e.typ = 2, itm.ttl, itm.GetDeadline(), reflect.TypeOf(itm.GetValue())
+			t.mu.Unlock()
+			ttl := e.ttl
+			if remaining < ttl {
+				ttl = remaining
+			}
+			t.demotions.Add(1)
+			t.l2.SetWithCleanup(key, itm.GetValue(), &ttl, t.tierCleanup(key, e, 2))
+			return
+		}
+		delete(t.entries, key)
+		t.mu.Unlock()
+
+		if e.cleanup != nil {
+			e.cleanup(itm)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package ttlmap_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/packaged/ttlmap"
+)
+
+func TestTieredDemoteAndPromote(t *testing.T) {
+	l1 := ttlmap.New(ttlmap.WithDefaultTTL(100*time.Millisecond), ttlmap.WithMaxLifetime(time.Hour),
+		ttlmap.WithCleanupDuration(5*time.Millisecond))
+	l2 := ttlmap.New(ttlmap.WithDefaultTTL(time.Hour))
+	defer l1.Close()
+	defer l2.Close()
+	cache := ttlmap.NewTiered(l1, l2)
+
+	var cleanups int32
+	cache.SetWithCleanup("k", "v", nil, func(item *ttlmap.Item) { atomic.AddInt32(&cleanups, 1) })
+	itm, _ := l1.GetItem("k")
+	deadline := itm.GetDeadline()
+
+	// Wait for L1 to expire the item and demote it
+	time.Sleep(150 * time.Millisecond)
+	if l1.Has("k") {
+		t.Fatalf("expected item to have left L1")
+	}
+	if !l2.Has("k") {
+		t.Fatalf("expected item to be demoted into L2")
+	}
+	if ttl := time.Until(*l2.GetExpiry("k")); ttl > 100*time.Millisecond {
+		t.Fatalf("expected the demoted item to keep its own TTL, got %v", ttl)
+	}
+	if atomic.LoadInt32(&cleanups) != 0 {
+		t.Fatalf("cleanup should not fire on demotion")
+	}
+
+	v, ok := cache.Get("k")
+	if !ok || v.(string) != "v" {
+		t.Fatalf("expected tiered get to find demoted value")
+	}
+	if !l1.Has("k") || l2.Has("k") {
+		t.Fatalf("expected item to be promoted back into L1")
+	}
+	if itm, _ = l1.GetItem("k"); !itm.GetDeadline().Equal(deadline) || time.Until(itm.GetExpiry()) > 100*time.Millisecond {
+		t.Fatalf("expected the promoted item to keep its TTL and deadline")
+	}
+
+	stats := cache.Stats()
+	if stats.L1.Misses != 1 || stats.L2.Hits != 1 || stats.Promotions != 1 || stats.Demotions != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	cache.Remove("k")
+	if _, ok := cache.Get("k"); ok {
+		t.Fatalf("expected removed key to be missing from both tiers")
+	}
+	if atomic.LoadInt32(&cleanups) != 1 {
+		t.Fatalf("expected cleanup to fire once on remove, got %d", cleanups)
+	}
+}
+
+func TestTieredDemotionKeepsDeadline(t *testing.T) {
+	l1 := ttlmap.New(ttlmap.WithDefaultTTL(time.Hour), ttlmap.WithMaxLifetime(time.Second),
+		ttlmap.WithCleanupDuration(5*time.Millisecond))
+	l2 := ttlmap.New(ttlmap.WithDefaultTTL(time.Hour))
+	defer l1.Close()
+	defer l2.Close()
+	cache := ttlmap.NewTiered(l1, l2)
+
+	ttl := 20 * time.Millisecond
+	cache.Set("k", "v", &ttl)
+	itm, _ := l1.GetItem("k")
+	deadline := itm.GetDeadline()
+
+	for i := 0; i < 100 && !l2.Has("k"); i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !l2.Has("k") {
+		t.Fatalf("expected item to be demoted into L2")
+	}
+	// L2 must not keep the item past the deadline it had in L1
+	if expiry := l2.GetExpiry("k"); expiry.After(deadline) || time.Until(*expiry) > ttl {
+		t.Fatalf("expected the demoted item to keep its TTL within its deadline, expires %v deadline %v", expiry, deadline)
+	}
+}
+
+func TestTieredDemotionCapsTTLAtDeadline(t *testing.T) {
+	l1 := ttlmap.New(ttlmap.WithMaxLifetime(50*time.Millisecond), ttlmap.WithCleanupDuration(5*time.Millisecond))
+	l2 := ttlmap.New()
+	defer l1.Close()
+	defer l2.Close()
+	cache := ttlmap.NewTiered(l1, l2)
+
+	ttl := 40 * time.Millisecond
+	cache.Set("k", "v", &ttl)
+	itm, _ := l1.GetItem("k")
+	deadline := itm.GetDeadline()
+
+	for i := 0; i < 100 && !l2.Has("k"); i++ {
+		time.Sleep(2 * time.Millisecond)
+	}
+	// Allow for the time taken to store the item in L2
+	if expiry := l2.GetExpiry("k"); expiry != nil && expiry.After(deadline.Add(time.Millisecond)) {
+		t.Fatalf("expected the demoted item to expire by its deadline, expires %v deadline %v", expiry, deadline)
+	}
+}
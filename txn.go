@@ -0,0 +1,101 @@
+package ttlmap
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrKeyNotInTxn is returned when a transaction accesses a key it was not started with
+var ErrKeyNotInTxn = errors.New("ttlmap: key is not part of the transaction")
+
+// Tx reads and buffers writes to the keys of a transaction, writes are applied when the transaction commits
+type Tx struct {
+	cache  CacheMap
+	shards map[string]*CacheMapShared
+	order  []string
+	writes map[string]*Item // a nil item deletes the key
+}
+
+// Txn locks the shards of the given keys in a deterministic order and runs fn.
+// Old shards are locked before new shards while resharding, matching the order migration locks them.
+// If fn returns nil its writes are applied atomically, including removal callbacks, otherwise they are discarded.
+// fn must only use the Tx to access the cache.
+func (m CacheMap) Txn(keys []string, fn func(tx *Tx) error) error {
+	owners, shards := m.shards.lockKeys(keys)
+	tx := &Tx{cache: m, shards: owners, writes: make(map[string]*Item)}
+	defer func() {
+		// Dependents are only invalidated once every shard is released, as they may live in a locked shard
+		var pending []string
+		for _, shard := range shards {
+			pending = append(pending, shard.pending...)
+			shard.pending = nil
+			shard.Unlock()
+		}
+		if len(pending) > 0 {
+			m.deps.invalidate(pending)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	tx.commit()
+	return nil
+}
+
+// Get returns the value for the key, including writes made earlier in the transaction
+func (tx *Tx) Get(key string) (interface{}, bool) {
+	shard, ok := tx.shards[key]
+	if !ok {
+		return nil, false
+	}
+	if itm, ok := tx.writes[key]; ok {
+		if itm == nil {
+			return nil, false
+		}
+		return itm.GetValue(), true
+	}
+	itm, ok := shard.items[key]
+	if !ok || itm.Expired() {
+		return nil, false
+	}
+	return itm.GetValue(), true
+}
+
+// Set stores the value under the key when the transaction commits
+func (tx *Tx) Set(key string, value interface{}, duration *time.Duration) error {
+	if _, ok := tx.shards[key]; !ok {
+		return ErrKeyNotInTxn
+	}
+	tx.write(key, tx.cache.newItem(value, itemOptions{duration: duration}))
+	return nil
+}
+
+// Delete removes the key when the transaction commits
+func (tx *Tx) Delete(key string) error {
+	if _, ok := tx.shards[key]; !ok {
+		return ErrKeyNotInTxn
+	}
+	tx.write(key, nil)
+	return nil
+}
+
+func (tx *Tx) write(key string, itm *Item) {
+	if _, ok := tx.writes[key]; !ok {
+		tx.order = append(tx.order, key)
+	}
+	tx.writes[key] = itm
+}
+
+// commit applies the buffered writes in the order the keys were first written
+func (tx *Tx) commit() {
+	for _, key := range tx.order {
+		shard := tx.shards[key]
+		if itm := tx.writes[key]; itm != nil {
+			shard.set(key, itm)
+			tx.cache.grow(shard)
+		} else {
+			shard.remove(key, RemovedDeleted)
+		}
+	}
+}
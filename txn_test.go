@@ -0,0 +1,84 @@
+package ttlmap_test
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/packaged/ttlmap"
+)
+
+func TestTxn(t *testing.T) {
+	cache := ttlmap.New()
+	defer cache.Close()
+
+	removed := 0
+	cache.SetWithCleanup("user:1", "alice", nil, func(*ttlmap.Item) { removed++ })
+	cache.Set("email:alice", "user:1", nil)
+
+	errAbort := errors.New("abort")
+	err := cache.Txn([]string{"user:1", "email:alice", "email:bob"}, func(tx *ttlmap.Tx) error {
+		tx.Set("user:1", "bob", nil)
+		tx.Delete("email:alice")
+		tx.Set("email:bob", "user:1", nil)
+		if v, _ := tx.Get("user:1"); v != "bob" {
+			t.Fatalf("expected transaction to read its own writes")
+		}
+		return errAbort
+	})
+	if err != errAbort {
+		t.Fatalf("expected abort error, got %v", err)
+	}
+	if v, _ := cache.Get("user:1"); v != "alice" || !cache.Has("email:alice") || cache.Has("email:bob") {
+		t.Fatalf("expected rolled back transaction to leave the cache unchanged")
+	}
+
+	err = cache.Txn([]string{"user:1", "email:alice", "email:bob"}, func(tx *ttlmap.Tx) error {
+		if err := tx.Set("other", 1, nil); err != ttlmap.ErrKeyNotInTxn {
+			t.Fatalf("expected ErrKeyNotInTxn, got %v", err)
+		}
+		tx.Delete("user:1")
+		tx.Set("user:1", "bob", nil)
+		tx.Delete("email:alice")
+		return tx.Set("email:bob", "user:1", nil)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v, _ := cache.Get("user:1"); v != "bob" || cache.Has("email:alice") || !cache.Has("email:bob") {
+		t.Fatalf("expected committed transaction to apply every write")
+	}
+	if removed != 0 {
+		t.Fatalf("expected replaced item cleanup not to fire, got %d", removed)
+	}
+}
+
+func TestTxnNoDeadlock(t *testing.T) {
+	cache := ttlmap.New(ttlmap.WithShardSize(4))
+	defer cache.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			keys := []string{"a" + strconv.Itoa(i%7), "b" + strconv.Itoa(i%5), "c" + strconv.Itoa(i%3)}
+			if i%2 == 0 {
+				keys[0], keys[2] = keys[2], keys[0]
+			}
+			cache.Txn(keys, func(tx *ttlmap.Tx) error {
+				for _, key := range keys {
+					v, _ := tx.Get(key)
+					n, _ := v.(int)
+					tx.Set(key, n+1, nil)
+				}
+				return nil
+			})
+		}(i)
+	}
+	wg.Wait()
+	if v, _ := cache.Get("c0"); v != 17 {
+		t.Fatalf("expected 17 increments of c0, got %v", v)
+	}
+}
@@ -0,0 +1,61 @@
+package ttlmap
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrVersionMismatch is matched by a VersionError using errors.Is
+var ErrVersionMismatch = errors.New("ttlmap: item version mismatch")
+
+// VersionError is returned when an item was modified, expired or removed since its version was read
+type VersionError struct {
+	Key      string
+	Expected uint64
+	Current  uint64 // zero when the item no longer exists
+}
+
+func (e *VersionError) Error() string {
+	return fmt.Sprintf("ttlmap: item %q has version %d, expected %d", e.Key, e.Current, e.Expected)
+}
+
+func (e *VersionError) Unwrap() error {
+	return ErrVersionMismatch
+}
+
+// GetWithVersion retrieves an item from the map with its version, and increase its expiry time if found
+func (m CacheMap) GetWithVersion(key string) (interface{}, uint64, bool) {
+	shard := m.rlockShard(key)
+	itm, ok := shard.items[key]
+	var value interface{}
+	var version uint64
+	if ok && !itm.Expired() && itm.access(true) {
+		value, version = itm.GetValue(), itm.version
+	} else {
+		ok = false
+	}
+	shard.RUnlock()
+	m.stats.lookup(ok)
+	if itm != nil && itm.Expired() {
+		m.evict(key, itm)
+	}
+	return value, version, ok
+}
+
+// SetIfVersion sets the value only if the stored item still has the given version, otherwise a *VersionError is returned
+func (m CacheMap) SetIfVersion(key string, value interface{}, version uint64, duration *time.Duration) error {
+	itm := m.newItem(value, itemOptions{duration: duration})
+	shard := m.lockShard(key)
+	defer shard.unlock()
+	existing, ok := shard.live(key)
+	if !ok {
+		return &VersionError{Key: key, Expected: version}
+	}
+	if existing.version != version {
+		return &VersionError{Key: key, Expected: version, Current: existing.version}
+	}
+	shard.set(key, itm)
+	m.grow(shard)
+	return nil
+}
@@ -0,0 +1,53 @@
+package ttlmap_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/packaged/ttlmap"
+)
+
+func TestSetIfVersion(t *testing.T) {
+	cache := ttlmap.New()
+	defer cache.Close()
+
+	cache.Set("k", 1, nil)
+	_, version, ok := cache.GetWithVersion("k")
+	if !ok || version == 0 {
+		t.Fatalf("expected a version for the stored item")
+	}
+
+	if err := cache.SetIfVersion("k", 2, version, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, next, _ := cache.GetWithVersion("k")
+	if next <= version {
+		t.Fatalf("expected version to increase, %d after %d", next, version)
+	}
+
+	// The read version is stale after a write
+	err := cache.SetIfVersion("k", 3, version, nil)
+	var versionErr *ttlmap.VersionError
+	if !errors.As(err, &versionErr) || versionErr.Current != next || !errors.Is(err, ttlmap.ErrVersionMismatch) {
+		t.Fatalf("expected version error, got %v", err)
+	}
+
+	// In place updates change the version
+	cache.CompareAndSwap("k", 2, 4, nil)
+	if err = cache.SetIfVersion("k", 5, next, nil); !errors.Is(err, ttlmap.ErrVersionMismatch) {
+		t.Fatalf("expected version error after swap, got %v", err)
+	}
+
+	// Expired and removed items fail
+	ttl := time.Millisecond
+	cache.Set("expires", 1, &ttl)
+	itm, _ := cache.GetItem("expires")
+	time.Sleep(5 * time.Millisecond)
+	if err = cache.SetIfVersion("expires", 2, itm.GetVersion(), nil); !errors.Is(err, ttlmap.ErrVersionMismatch) {
+		t.Fatalf("expected version error for expired item, got %v", err)
+	}
+	if cache.Has("expires") {
+		t.Fatalf("expected failed write not to store the value")
+	}
+}